@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/provideplatform/ident/common"
 	"github.com/provideplatform/ident/token"
@@ -41,6 +42,7 @@ const runloopSleepInterval = 250 * time.Millisecond
 const runloopTickInterval = 5000 * time.Millisecond
 const jwtVerifierRefreshInterval = 60 * time.Second
 const jwtVerifierGracePeriod = 60 * time.Second
+const tracingServiceName = "privacy-api"
 
 var (
 	cancelF     context.CancelFunc
@@ -62,6 +64,7 @@ func init() {
 func main() {
 	common.Log.Debugf("starting privacy API...")
 	installSignalHandlers()
+	privacycommon.RequireTracing(tracingServiceName)
 
 	runAPI()
 
@@ -84,7 +87,7 @@ func main() {
 			}
 		case sig := <-sigs:
 			common.Log.Debugf("received signal: %s", sig)
-			srv.Shutdown(shutdownCtx)
+			shutdownServer()
 			shutdown()
 		case <-shutdownCtx.Done():
 			close(sigs)
@@ -104,6 +107,20 @@ func installSignalHandlers() {
 	shutdownCtx, cancelF = context.WithCancel(context.Background())
 }
 
+// shutdownServer gracefully shuts down the API server, waiting for in-flight
+// requests (i.e., proofs) to complete until the shutdown timeout elapses
+func shutdownServer() {
+	ctx, cancel := context.WithTimeout(context.Background(), privacycommon.ShutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		common.Log.Warningf("failed to gracefully shutdown privacy API within %s; %s", privacycommon.ShutdownTimeout, err.Error())
+	}
+
+	privacycommon.ShutdownTracing(ctx)
+}
+
 func shutdown() {
 	if atomic.AddUint32(&closing, 1) == 1 {
 		common.Log.Debug("shutting down privacy API")
@@ -118,7 +135,10 @@ func runAPI() {
 	r.Use(provide.CORSMiddleware())
 
 	r.GET("/status", statusHandler)
+	r.GET("/status/ready", readinessHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	r.Use(privacycommon.TracingMiddleware())
 	r.Use(token.AuthMiddleware())
 	r.Use(common.AccountingMiddleware())
 	r.Use(common.RateLimitingMiddleware())
@@ -141,7 +161,24 @@ func runAPI() {
 }
 
 func statusHandler(c *gin.Context) {
-	provide.Render(nil, 204, c)
+	provide.Render(map[string]interface{}{
+		"proving": prover.ProvingPoolStatus(),
+	}, 200, c)
+}
+
+// readinessHandler renders 200 when postgres, NATS jetstream, the default vault and redis
+// (if configured) are healthy, and 503 otherwise, along with the health of each dependency
+func readinessHandler(c *gin.Context) {
+	status := 200
+	checks, healthy := prover.CheckHealth()
+	if !healthy || shuttingDown() {
+		status = 503
+	}
+
+	provide.Render(map[string]interface{}{
+		"ready":        status == 200,
+		"dependencies": checks,
+	}, status, c)
 }
 
 func shuttingDown() bool {
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -25,18 +26,25 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/kthomas/go-redisutil"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/provideplatform/privacy/common"
-	_ "github.com/provideplatform/privacy/prover"
+	"github.com/provideplatform/privacy/prover"
+
+	provide "github.com/provideplatform/provide-go/common"
 )
 
 const natsStreamingSubscriptionStatusTickerInterval = 5 * time.Second
 const natsStreamingSubscriptionStatusSleepInterval = 250 * time.Millisecond
+const tracingServiceName = "privacy-consumer"
 
 var (
 	cancelF     context.CancelFunc
 	closing     uint32
 	shutdownCtx context.Context
+
+	srv *http.Server
 )
 
 func init() {
@@ -55,6 +63,9 @@ func main() {
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
 	shutdownCtx, cancelF = context.WithCancel(context.Background())
 
+	common.RequireTracing(tracingServiceName)
+	runHealthServer()
+
 	common.Log.Debugf("running dedicated NATS streaming subscription consumer main()")
 	timer := time.NewTicker(natsStreamingSubscriptionStatusTickerInterval)
 	defer timer.Stop()
@@ -62,10 +73,14 @@ func main() {
 	for !shuttingDown() {
 		select {
 		case <-timer.C:
-			// TODO: check NATS subscription statuses
+			err := prover.CheckNatsSubscriptions()
+			if err != nil {
+				common.Log.Warningf("NATS jetstream subscription health check failed; %s", err.Error())
+			}
 		case sig := <-sigs:
 			common.Log.Infof("received signal: %s", sig)
-			common.Log.Warningf("NATS streaming connection subscriptions are not yet being drained...")
+			prover.DrainNatsSubscriptions(common.ShutdownTimeout)
+			shutdownHealthServer()
 			shutdown()
 		case <-shutdownCtx.Done():
 			close(sigs)
@@ -78,6 +93,53 @@ func main() {
 	cancelF()
 }
 
+// runHealthServer serves the liveness, readiness and metrics endpoints of the consumer
+func runHealthServer() {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	r.GET("/status/live", livenessHandler)
+	r.GET("/status/ready", readinessHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	srv = &http.Server{
+		Addr:    common.ConsumerHealthListenAddr,
+		Handler: r,
+	}
+
+	go srv.ListenAndServe()
+	common.Log.Debugf("consumer health endpoints listening on %s", common.ConsumerHealthListenAddr)
+}
+
+func shutdownHealthServer() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		common.Log.Warningf("failed to gracefully shutdown consumer health endpoints; %s", err.Error())
+	}
+
+	common.ShutdownTracing(ctx)
+}
+
+func livenessHandler(c *gin.Context) {
+	provide.Render(nil, 204, c)
+}
+
+// readinessHandler renders 200 when each NATS jetstream subscription of the consumer is valid,
+// and 503 when any subscription is invalid or the consumer is draining
+func readinessHandler(c *gin.Context) {
+	status := 200
+	if !prover.NatsSubscriptionsReady() {
+		status = 503
+	}
+
+	provide.Render(map[string]interface{}{
+		"subscriptions": prover.NatsSubscriptionsStatus(),
+	}, status, c)
+}
+
 func shutdown() {
 	if atomic.AddUint32(&closing, 1) == 1 {
 		common.Log.Debug("shutting down dedicated NATS streaming subscription consumer")
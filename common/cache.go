@@ -0,0 +1,261 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"container/list"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Cache is a bounded, thread-safe LRU cache; each entry is added with its approximate
+// size in bytes and the least recently used entries are evicted when the total size
+// of the cached entries exceeds the capacity of the cache
+type Cache struct {
+	capacity int64
+	size     int64
+
+	entries map[string]*list.Element
+	lru     *list.List
+	mutex   sync.Mutex
+}
+
+type cacheEntry struct {
+	key  string
+	val  interface{}
+	size int64
+}
+
+// NewCache initializes a new LRU cache with the given capacity in bytes; a cache
+// with a capacity of zero or less does not retain any entries
+func NewCache(capacity int64) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// Add the given value to the cache with the given approximate size in bytes, replacing any
+// existing entry for the key; returns false if the value is larger than the cache capacity
+func (c *Cache) Add(key string, val interface{}, size int64) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	if size > c.capacity {
+		return false
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:  key,
+		val:  val,
+		size: size,
+	})
+	c.size += size
+
+	for c.size > c.capacity {
+		c.remove(c.lru.Back())
+	}
+
+	return true
+}
+
+// Get the cached value for the given key, marking it as the most recently used entry
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).val, true
+}
+
+// Remove the cached value for the given key, if any
+func (c *Cache) Remove(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// RemovePrefix removes the cached values for all keys with the given prefix and
+// returns the number of removed entries
+func (c *Cache) RemovePrefix(prefix string) int {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the approximate size in bytes of the cached entries
+func (c *Cache) Size() int64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.size
+}
+
+// remove the given element; the cache mutex must be held by the caller
+func (c *Cache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// SizeOf returns the approximate in-memory size in bytes of the given value, including the
+// memory it references; memory referenced more than once is counted once
+func SizeOf(val interface{}) int64 {
+	if val == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(val)
+	return int64(v.Type().Size()) + referencedSize(v, map[uintptr]bool{})
+}
+
+// referencedSize returns the size of the memory referenced by the given value, excluding the value itself
+func referencedSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		return int64(v.Elem().Type().Size()) + referencedSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr {
+			return referencedSize(elem, seen)
+		}
+		return int64(elem.Type().Size()) + referencedSize(elem, seen)
+	case reflect.Slice:
+		if v.IsNil() || v.Cap() == 0 || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if hasReferences(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += referencedSize(v.Index(i), seen)
+			}
+		}
+		return size
+	case reflect.Array:
+		size := int64(0)
+		if hasReferences(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += referencedSize(v.Index(i), seen)
+			}
+		}
+		return size
+	case reflect.Struct:
+		size := int64(0)
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), seen)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+
+		size := int64(0)
+		iter := v.MapRange()
+		for iter.Next() {
+			size += int64(iter.Key().Type().Size()) + referencedSize(iter.Key(), seen)
+			size += int64(iter.Value().Type().Size()) + referencedSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.String:
+		return int64(v.Len())
+	}
+
+	return 0
+}
+
+// hasReferences returns true if values of the given type may reference other memory
+func hasReferences(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.String:
+		return true
+	case reflect.Array:
+		return hasReferences(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasReferences(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -19,6 +19,7 @@ package common
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,13 +38,172 @@ var (
 
 	// DefaultVault for this privacy instance
 	DefaultVault *vault.Vault
+
+	// RollupInterval is the interval at which the queued notes of provers in rollup mode are proven in batches
+	RollupInterval time.Duration
+
+	// ArtifactCache holds decoded prover artifacts (i.e., constraint systems, proving and verifying keys),
+	// bounded by PROVER_ARTIFACT_CACHE_SIZE bytes of their approximate in-memory size
+	ArtifactCache *Cache
+
+	// ProvingWorkers is the maximum number of proofs generated concurrently by the API, or by the consumer proving workers
+	ProvingWorkers int
+
+	// ProvingQueueDepth is the maximum number of proofs queued while all proving workers are busy
+	ProvingQueueDepth int
+
+	// ProvingConcurrencyPerProver is the maximum number of running and queued proofs for a single prover
+	ProvingConcurrencyPerProver int
+
+	// ProvingMemoryLimit is the maximum estimated memory in bytes used by running proofs; zero is unlimited
+	ProvingMemoryLimit int64
+
+	// ProvingQueueTimeout is the maximum duration a proof is queued before it is rejected
+	ProvingQueueTimeout time.Duration
+
+	// MaxProvingDuration is the default max duration of proof generation, unless configured for a prover
+	MaxProvingDuration time.Duration
+
+	// ShutdownTimeout is the max duration to wait for in-flight requests, i.e. proofs, during graceful shutdown
+	ShutdownTimeout time.Duration
+
+	// ConsumerHealthListenAddr is the address on which the consumer serves its liveness and readiness endpoints
+	ConsumerHealthListenAddr string
+
+	// SimulatedContractDeployerEnabled is a flag that indicates if verifier contracts may be deployed to the
+	// in-process simulated chain; the simulated chain is lost on restart and is only intended for tests
+	SimulatedContractDeployerEnabled bool
 )
 
+const defaultRollupInterval = time.Minute
+
+const defaultArtifactCacheSize = int64(1024 * 1024 * 1024)
+
+const defaultProvingWorkers = 2
+const defaultProvingQueueDepth = 32
+const defaultProvingConcurrencyPerProver = 8
+const defaultProvingQueueTimeout = 30 * time.Second
+const defaultMaxProvingDuration = 10 * time.Minute
+const defaultShutdownTimeout = time.Minute
+const defaultConsumerHealthListenAddr = "0.0.0.0:8086"
+
 func init() {
 	godotenv.Load()
 	ConsumeNATSStreamingSubscriptions = strings.ToLower(os.Getenv("CONSUME_NATS_STREAMING_SUBSCRIPTIONS")) == "true"
+	SimulatedContractDeployerEnabled = strings.ToLower(os.Getenv("SIMULATED_CONTRACT_DEPLOYER_ENABLED")) == "true"
 
 	requireLogger()
+	requireRollupInterval()
+	requireArtifactCache()
+	requireProvingWorkers()
+	requireProvingTimeouts()
+	requireConsumerHealthListenAddr()
+}
+
+func requireConsumerHealthListenAddr() {
+	ConsumerHealthListenAddr = defaultConsumerHealthListenAddr
+	if os.Getenv("CONSUMER_HEALTH_LISTEN_ADDR") != "" {
+		ConsumerHealthListenAddr = os.Getenv("CONSUMER_HEALTH_LISTEN_ADDR")
+	}
+}
+
+func requireProvingTimeouts() {
+	MaxProvingDuration = defaultMaxProvingDuration
+	if os.Getenv("PROVER_MAX_PROVING_DURATION") != "" {
+		duration, err := time.ParseDuration(os.Getenv("PROVER_MAX_PROVING_DURATION"))
+		if err != nil || duration <= 0 {
+			Log.Warningf("invalid PROVER_MAX_PROVING_DURATION; using default max proving duration of %s", defaultMaxProvingDuration)
+		} else {
+			MaxProvingDuration = duration
+		}
+	}
+
+	ShutdownTimeout = defaultShutdownTimeout
+	if os.Getenv("SHUTDOWN_TIMEOUT") != "" {
+		timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT"))
+		if err != nil || timeout <= 0 {
+			Log.Warningf("invalid SHUTDOWN_TIMEOUT; using default shutdown timeout of %s", defaultShutdownTimeout)
+		} else {
+			ShutdownTimeout = timeout
+		}
+	}
+}
+
+func requireProvingWorkers() {
+	ProvingWorkers = defaultProvingWorkers
+	if os.Getenv("PROVER_WORKERS") != "" {
+		workers, err := strconv.Atoi(os.Getenv("PROVER_WORKERS"))
+		if err != nil || workers <= 0 {
+			Log.Warningf("invalid PROVER_WORKERS; using default of %d proving workers", defaultProvingWorkers)
+		} else {
+			ProvingWorkers = workers
+		}
+	}
+
+	ProvingQueueDepth = defaultProvingQueueDepth
+	if os.Getenv("PROVER_QUEUE_DEPTH") != "" {
+		depth, err := strconv.Atoi(os.Getenv("PROVER_QUEUE_DEPTH"))
+		if err != nil || depth < 0 {
+			Log.Warningf("invalid PROVER_QUEUE_DEPTH; using default proving queue depth of %d", defaultProvingQueueDepth)
+		} else {
+			ProvingQueueDepth = depth
+		}
+	}
+
+	ProvingConcurrencyPerProver = defaultProvingConcurrencyPerProver
+	if os.Getenv("PROVER_CONCURRENCY_PER_PROVER") != "" {
+		concurrency, err := strconv.Atoi(os.Getenv("PROVER_CONCURRENCY_PER_PROVER"))
+		if err != nil || concurrency <= 0 {
+			Log.Warningf("invalid PROVER_CONCURRENCY_PER_PROVER; using default of %d concurrent proofs per prover", defaultProvingConcurrencyPerProver)
+		} else {
+			ProvingConcurrencyPerProver = concurrency
+		}
+	}
+
+	if os.Getenv("PROVER_MEMORY_LIMIT") != "" {
+		limit, err := strconv.ParseInt(os.Getenv("PROVER_MEMORY_LIMIT"), 10, 64)
+		if err != nil || limit < 0 {
+			Log.Warningf("invalid PROVER_MEMORY_LIMIT; proving memory is not limited")
+		} else {
+			ProvingMemoryLimit = limit
+		}
+	}
+
+	ProvingQueueTimeout = defaultProvingQueueTimeout
+	if os.Getenv("PROVER_QUEUE_TIMEOUT") != "" {
+		timeout, err := time.ParseDuration(os.Getenv("PROVER_QUEUE_TIMEOUT"))
+		if err != nil || timeout <= 0 {
+			Log.Warningf("invalid PROVER_QUEUE_TIMEOUT; using default proving queue timeout of %s", defaultProvingQueueTimeout)
+		} else {
+			ProvingQueueTimeout = timeout
+		}
+	}
+}
+
+func requireArtifactCache() {
+	size := defaultArtifactCacheSize
+	if os.Getenv("PROVER_ARTIFACT_CACHE_SIZE") != "" {
+		val, err := strconv.ParseInt(os.Getenv("PROVER_ARTIFACT_CACHE_SIZE"), 10, 64)
+		if err != nil {
+			Log.Warningf("invalid PROVER_ARTIFACT_CACHE_SIZE; using default artifact cache size of %d bytes", defaultArtifactCacheSize)
+		} else {
+			size = val
+		}
+	}
+
+	ArtifactCache = NewCache(size)
+}
+
+func requireRollupInterval() {
+	RollupInterval = defaultRollupInterval
+	if os.Getenv("PROVER_ROLLUP_INTERVAL") != "" {
+		interval, err := time.ParseDuration(os.Getenv("PROVER_ROLLUP_INTERVAL"))
+		if err != nil || interval <= 0 {
+			Log.Warningf("invalid PROVER_ROLLUP_INTERVAL; using default rollup interval of %s", defaultRollupInterval)
+			return
+		}
+		RollupInterval = interval
+	}
 }
 
 func requireLogger() {
@@ -0,0 +1,247 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dbconf "github.com/kthomas/go-db-config"
+	natsutil "github.com/kthomas/go-natsutil"
+	"github.com/kthomas/go-redisutil"
+	"github.com/nats-io/nats.go"
+	"github.com/provideplatform/provide-go/api/vault"
+	"github.com/provideplatform/provide-go/common/util"
+)
+
+// MigrationsPath is the path of the database migrations, relative to the working directory
+const MigrationsPath = "./ops/migrations"
+
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck is the result of checking the health of a dependency
+type HealthCheck struct {
+	Healthy bool                   `json:"healthy"`
+	Latency string                 `json:"latency"`
+	Error   *string                `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// CheckHealth concurrently checks the health of each dependency of the privacy instance, i.e.
+// postgres, the given NATS jetstream stream, the default vault and redis if it is configured;
+// the results are keyed by dependency and true is returned if each dependency is healthy
+func CheckHealth(stream string) (map[string]*HealthCheck, bool) {
+	checks := map[string]func() (map[string]interface{}, error){
+		"postgres": checkDatabase,
+		"nats": func() (map[string]interface{}, error) {
+			return checkNatsJetstream(stream)
+		},
+		"vault": checkVault,
+	}
+
+	if redisutil.RedisClient != nil || redisutil.RedisClusterClient != nil {
+		checks["redis"] = checkRedis
+	}
+
+	results := map[string]*HealthCheck{}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func() (map[string]interface{}, error)) {
+			defer wg.Done()
+			result := runHealthCheck(check)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			results[name] = result
+		}(name, check)
+	}
+	wg.Wait()
+
+	healthy := true
+	for name, result := range results {
+		if !result.Healthy {
+			Log.Warningf("%s health check failed; %s", name, *result.Error)
+			healthy = false
+		}
+	}
+
+	return results, healthy
+}
+
+func runHealthCheck(check func() (map[string]interface{}, error)) (result *HealthCheck) {
+	startedAt := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result = &HealthCheck{
+				Error: StringOrNil(fmt.Sprintf("recovered from panic during health check; %s", r)),
+			}
+		}
+		result.Latency = time.Since(startedAt).String()
+	}()
+
+	details, err := check()
+	result = &HealthCheck{
+		Healthy: err == nil,
+		Details: details,
+	}
+	if err != nil {
+		result.Error = StringOrNil(err.Error())
+	}
+
+	return result
+}
+
+// checkDatabase verifies postgres connectivity and that the schema is migrated to the latest
+// version of the migrations shipped with this instance, if they are present
+func checkDatabase() (map[string]interface{}, error) {
+	db := dbconf.DatabaseConnection()
+	if db == nil {
+		return nil, fmt.Errorf("database connection not established")
+	}
+
+	err := db.DB().Ping()
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping database; %s", err.Error())
+	}
+
+	var version uint
+	var dirty bool
+	err = db.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Row().Scan(&version, &dirty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve database migration version; %s", err.Error())
+	}
+
+	details := map[string]interface{}{
+		"migration_version": version,
+		"dirty":             dirty,
+	}
+
+	if dirty {
+		return details, fmt.Errorf("database migration version %d is dirty", version)
+	}
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		Log.Debugf("skipping database migration version check; %s", err.Error())
+		return details, nil
+	}
+	details["latest_migration_version"] = latest
+
+	if version < latest {
+		return details, fmt.Errorf("database migration version %d is behind latest migration version %d", version, latest)
+	}
+
+	return details, nil
+}
+
+// latestMigrationVersion returns the version of the latest migration in MigrationsPath
+func latestMigrationVersion() (uint, error) {
+	files, err := ioutil.ReadDir(MigrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations; %s", err.Error())
+	}
+
+	latest := uint(0)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".up.sql") {
+			continue
+		}
+
+		version, err := strconv.ParseUint(strings.Split(file.Name(), "_")[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", MigrationsPath)
+	}
+
+	return latest, nil
+}
+
+// checkNatsJetstream verifies the shared NATS connection and the availability of the given jetstream stream
+func checkNatsJetstream(stream string) (map[string]interface{}, error) {
+	if !natsutil.IsSharedNatsConnectionValid() {
+		err := natsutil.EstablishSharedNatsConnection(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to establish shared NATS connection; %s", err.Error())
+		}
+	}
+
+	js, err := natsutil.GetSharedJetstreamContext(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve NATS jetstream context; %s", err.Error())
+	}
+
+	info, err := js.StreamInfo(stream, nats.MaxWait(healthCheckTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve NATS jetstream stream: %s; %s", stream, err.Error())
+	}
+
+	return map[string]interface{}{
+		"stream":    stream,
+		"messages":  info.State.Msgs,
+		"consumers": info.State.Consumers,
+	}, nil
+}
+
+// checkVault verifies the default vault is reachable using the vault access token of this instance;
+// the check fails if the token has expired or was revoked
+func checkVault() (map[string]interface{}, error) {
+	if DefaultVault == nil {
+		return nil, fmt.Errorf("default vault not resolved")
+	}
+
+	_, err := vault.ListKeys(util.DefaultVaultAccessJWT, DefaultVault.ID.String(), map[string]interface{}{
+		"rpp": 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to access default vault: %s; %s", DefaultVault.ID.String(), err.Error())
+	}
+
+	return map[string]interface{}{
+		"vault_id": DefaultVault.ID.String(),
+	}, nil
+}
+
+// checkRedis verifies connectivity to the configured redis client or cluster client
+func checkRedis() (map[string]interface{}, error) {
+	var err error
+	if redisutil.RedisClusterClient != nil {
+		err = redisutil.RedisClusterClient.Ping().Err()
+	} else {
+		err = redisutil.RedisClient.Ping().Err()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping redis; %s", err.Error())
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrWorkerPoolQueueFull is returned when a job is submitted to a worker pool with a full queue
+var ErrWorkerPoolQueueFull = errors.New("worker pool queue is full")
+
+// ErrWorkerPoolKeyLimitExceeded is returned when a job is submitted to a worker pool for a key
+// which has reached its concurrency limit
+var ErrWorkerPoolKeyLimitExceeded = errors.New("worker pool concurrency limit exceeded")
+
+// ErrWorkerPoolQueueTimeout is returned when a queued job is not started within the queue timeout
+var ErrWorkerPoolQueueTimeout = errors.New("timed out waiting for an available worker")
+
+// WorkerPool admits a bounded number of concurrent jobs; jobs which cannot be started
+// immediately are queued up to the queue depth and started in the order they were queued,
+// the jobs admitted for a single key (i.e., a prover id) are capped, and a job is only started
+// when its estimated memory cost fits within the memory limit of the pool, unless no other job
+// is running
+type WorkerPool struct {
+	workers     int
+	queueDepth  int
+	keyLimit    int
+	memoryLimit int64
+	timeout     time.Duration
+
+	running  int
+	memory   int64
+	admitted map[string]int
+
+	// queue holds the queued jobs in the order they were queued
+	queue []*queuedJob
+
+	// duration is the moving average duration of completed jobs, used to derive retry hints
+	duration time.Duration
+
+	mutex sync.Mutex
+}
+
+// queuedJob is a job waiting for a worker; ready is closed when the job is started
+type queuedJob struct {
+	cost    int64
+	ready   chan struct{}
+	started bool
+}
+
+// WorkerPoolStatus is a snapshot of the state of a worker pool
+type WorkerPoolStatus struct {
+	Workers     int   `json:"workers"`
+	Running     int   `json:"running"`
+	Queued      int   `json:"queued"`
+	QueueDepth  int   `json:"queue_depth"`
+	Memory      int64 `json:"memory"`
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+}
+
+// NewWorkerPool initializes a new worker pool; a key limit or memory limit of zero or less is unlimited
+func NewWorkerPool(workers, queueDepth, keyLimit int, memoryLimit int64, timeout time.Duration) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if queueDepth < 0 {
+		queueDepth = 0
+	}
+
+	return &WorkerPool{
+		workers:     workers,
+		queueDepth:  queueDepth,
+		keyLimit:    keyLimit,
+		memoryLimit: memoryLimit,
+		timeout:     timeout,
+		admitted:    map[string]int{},
+		queue:       make([]*queuedJob, 0),
+	}
+}
+
+// Acquire a worker for a job with the given key and estimated memory cost in bytes, blocking
+// while the job is queued; a job is only started immediately when no job is queued ahead of it,
+// and a queued job is abandoned and the context error returned when the given context is done.
+// The returned function must be called to release the worker when the job has completed
+func (p *WorkerPool) Acquire(ctx context.Context, key string, cost int64) (func(), error) {
+	p.mutex.Lock()
+
+	if p.keyLimit > 0 && p.admitted[key] >= p.keyLimit {
+		p.mutex.Unlock()
+		return nil, ErrWorkerPoolKeyLimitExceeded
+	}
+
+	if len(p.queue) == 0 && p.available(cost) {
+		p.admitted[key]++
+		p.running++
+		p.memory += cost
+		p.mutex.Unlock()
+		return p.releaseFunc(key, cost), nil
+	}
+
+	if len(p.queue) >= p.queueDepth {
+		p.mutex.Unlock()
+		return nil, ErrWorkerPoolQueueFull
+	}
+
+	job := &queuedJob{
+		cost:  cost,
+		ready: make(chan struct{}),
+	}
+	p.admitted[key]++
+	p.queue = append(p.queue, job)
+	p.mutex.Unlock()
+
+	queueCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		queueCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	select {
+	case <-job.ready:
+		return p.releaseFunc(key, cost), nil
+	case <-queueCtx.Done():
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if job.started {
+		// the job was started as the context was done, so its worker is released
+		p.running--
+		p.memory -= cost
+	} else {
+		p.dequeue(job)
+	}
+	p.release(key)
+
+	// the abandoned job may have blocked the jobs queued behind it
+	p.dispatch()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, ErrWorkerPoolQueueTimeout
+}
+
+// releaseFunc returns the function which releases the worker of a started job with the given
+// key and estimated memory cost, starting the queued jobs which fit in the released capacity
+func (p *WorkerPool) releaseFunc(key string, cost int64) func() {
+	startedAt := time.Now()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+
+			p.running--
+			p.memory -= cost
+			p.release(key)
+
+			elapsed := time.Since(startedAt)
+			if p.duration == 0 {
+				p.duration = elapsed
+			} else {
+				p.duration = (p.duration*4 + elapsed) / 5
+			}
+
+			p.dispatch()
+		})
+	}
+}
+
+// dispatch starts the queued jobs in the order they were queued while the job at the head of
+// the queue can be started; the pool mutex must be held by the caller
+func (p *WorkerPool) dispatch() {
+	for len(p.queue) > 0 && p.available(p.queue[0].cost) {
+		job := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+
+		p.running++
+		p.memory += job.cost
+		job.started = true
+		close(job.ready)
+	}
+}
+
+// dequeue removes the given job from the queue; the pool mutex must be held by the caller
+func (p *WorkerPool) dequeue(job *queuedJob) {
+	for i := range p.queue {
+		if p.queue[i] == job {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// RetryAfter estimates the duration after which a rejected job may be admitted,
+// based on the average job duration and the number of running and queued jobs
+func (p *WorkerPool) RetryAfter() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	retryAfter := p.duration * time.Duration(p.running+len(p.queue)) / time.Duration(p.workers)
+	if retryAfter < time.Second {
+		return time.Second
+	}
+
+	return retryAfter
+}
+
+// Status returns a snapshot of the state of the worker pool
+func (p *WorkerPool) Status() *WorkerPoolStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return &WorkerPoolStatus{
+		Workers:     p.workers,
+		Running:     p.running,
+		Queued:      len(p.queue),
+		QueueDepth:  p.queueDepth,
+		Memory:      p.memory,
+		MemoryLimit: p.memoryLimit,
+	}
+}
+
+// available returns true if a job with the given estimated memory cost can be started;
+// the pool mutex must be held by the caller
+func (p *WorkerPool) available(cost int64) bool {
+	if p.running >= p.workers {
+		return false
+	}
+
+	return p.memoryLimit <= 0 || p.running == 0 || p.memory+cost <= p.memoryLimit
+}
+
+// release the admission of a job for the given key; the pool mutex must be held by the caller
+func (p *WorkerPool) release(key string) {
+	p.admitted[key]--
+	if p.admitted[key] <= 0 {
+		delete(p.admitted, key)
+	}
+}
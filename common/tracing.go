@@ -0,0 +1,185 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	natsutil "github.com/kthomas/go-natsutil"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/provideplatform/privacy"
+
+const tracingExporterOTLP = "otlp"
+const tracingExporterStdout = "stdout"
+
+var (
+	// Tracer creates the spans of the privacy instance; spans are not exported unless tracing is configured
+	Tracer = otel.Tracer(tracerName)
+
+	tracerProvider *sdktrace.TracerProvider
+)
+
+// RequireTracing configures the exporter of the spans of the given service using OTEL_TRACES_EXPORTER,
+// which is either otlp, i.e. to the collector at OTEL_EXPORTER_OTLP_ENDPOINT, or stdout; tracing is
+// disabled when no exporter is configured; the service name is overridden by OTEL_SERVICE_NAME
+func RequireTracing(serviceName string) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case tracingExporterOTLP:
+		exporter, err = otlptracehttp.New(context.Background())
+	case tracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "", "none":
+		Log.Debug("tracing exporter not configured; spans will not be exported")
+		return
+	default:
+		err = fmt.Errorf("unsupported exporter: %s", os.Getenv("OTEL_TRACES_EXPORTER"))
+	}
+
+	if err != nil {
+		Log.Warningf("failed to configure tracing exporter; spans will not be exported; %s", err.Error())
+		return
+	}
+
+	if os.Getenv("OTEL_SERVICE_NAME") != "" {
+		serviceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName)),
+	)
+	if err != nil {
+		Log.Warningf("failed to resolve tracing resource; %s", err.Error())
+		res = resource.Default()
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	Log.Debugf("exporting spans of %s using %s exporter", serviceName, strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")))
+}
+
+// ShutdownTracing flushes the spans which have not been exported and stops the tracing exporter
+func ShutdownTracing(ctx context.Context) {
+	if tracerProvider == nil {
+		return
+	}
+
+	err := tracerProvider.Shutdown(ctx)
+	if err != nil {
+		Log.Warningf("failed to shutdown tracing exporter; %s", err.Error())
+	}
+}
+
+// StartSpan starts a span with the given name and attributes as a child of the span in the given context
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the given error, if any, and ends the given span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// NatsJetstreamPublish publishes a NATS jetstream message using the shared jetstream context; the
+// trace context of the given context is carried in the message headers
+func NatsJetstreamPublish(ctx context.Context, subject string, payload []byte) (*nats.PubAck, error) {
+	ctx, span := StartSpan(ctx, fmt.Sprintf("nats publish %s", subject), semconv.MessagingSystemKey.String("nats"), semconv.MessagingDestinationKey.String(subject))
+
+	js, err := natsutil.GetSharedJetstreamContext(nil)
+	if err != nil {
+		EndSpan(span, err)
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(http.Header(msg.Header)))
+
+	ack, err := js.PublishMsg(msg)
+	EndSpan(span, err)
+	return ack, err
+}
+
+// NatsTraceContext returns a context carrying the trace context from the headers of the given message, if any
+func NatsTraceContext(msg *nats.Msg) context.Context {
+	if msg.Header == nil {
+		return context.Background()
+	}
+
+	return otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(http.Header(msg.Header)))
+}
+
+// TracingMiddleware starts a server span for each request, as a child of the trace context of the
+// request headers, if any; the request context carries the span to the handlers
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx, span := Tracer.Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(c.Request.Method),
+				semconv.HTTPRouteKey.String(route),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
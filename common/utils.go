@@ -140,3 +140,35 @@ func NextPowerOfTwo(_n int) int {
 	}
 	return int(p)
 }
+
+// ParseInteger parses the given string as a base 10 integer, or as a base 16 integer
+// when it has an explicit 0x prefix; octal, binary and underscore-separated forms are rejected
+func ParseInteger(str string) (*big.Int, bool) {
+	str = strings.TrimSpace(str)
+
+	negative := strings.HasPrefix(str, "-")
+	if negative {
+		str = str[1:]
+	}
+
+	base := 10
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		base = 16
+		str = str[2:]
+	}
+
+	if str == "" || strings.HasPrefix(str, "+") || strings.HasPrefix(str, "-") {
+		return nil, false
+	}
+
+	value, valueOk := new(big.Int).SetString(str, base)
+	if !valueOk {
+		return nil, false
+	}
+
+	if negative {
+		value.Neg(value)
+	}
+
+	return value, true
+}
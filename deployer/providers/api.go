@@ -0,0 +1,52 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/provideplatform/privacy/common"
+)
+
+// ContractDeployerSimulated deploys contracts to a local, in-process simulated chain; the simulated
+// chain is not persisted, so this deployer is test-only and must be enabled with SIMULATED_CONTRACT_DEPLOYER_ENABLED
+const ContractDeployerSimulated = "simulated"
+
+// ContractDeployer provides a common interface to deploy on-chain artifacts (i.e., verifier contracts)
+type ContractDeployer interface {
+	Deploy(name string, source []byte) (*ContractDeployment, error)
+}
+
+// ContractDeployment is the result of a contract deployment
+type ContractDeployment struct {
+	Address         string
+	TransactionHash string
+}
+
+// ContractDeployerFactory resolves the contract deployer for the given identifier
+func ContractDeployerFactory(deployer string) (ContractDeployer, error) {
+	switch strings.ToLower(deployer) {
+	case ContractDeployerSimulated:
+		if !common.SimulatedContractDeployerEnabled {
+			return nil, fmt.Errorf("failed to resolve contract deployer; %s deployer is test-only and not enabled", deployer)
+		}
+		return simulatedContractDeployer(), nil
+	default:
+		return nil, fmt.Errorf("failed to resolve contract deployer; unknown or unsupported deployer: %s", deployer)
+	}
+}
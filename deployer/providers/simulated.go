@@ -0,0 +1,70 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/provideplatform/privacy/zkp/verifier"
+)
+
+var simulatedDeployer *SimulatedContractDeployer
+var simulatedDeployerOnce sync.Once
+
+// SimulatedContractDeployer deploys contracts to a shared in-process simulated chain
+type SimulatedContractDeployer struct {
+	auth    *bind.TransactOpts
+	backend *backends.SimulatedBackend
+	mutex   sync.Mutex
+}
+
+func simulatedContractDeployer() *SimulatedContractDeployer {
+	simulatedDeployerOnce.Do(func() {
+		simulatedDeployer = &SimulatedContractDeployer{}
+	})
+	return simulatedDeployer
+}
+
+// Deploy compiles the given solidity source and deploys the named contract to the simulated chain
+func (d *SimulatedContractDeployer) Deploy(name string, source []byte) (*ContractDeployment, error) {
+	contract, err := verifier.CompileContract(source, name)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.backend == nil {
+		d.backend, d.auth, err = verifier.NewSimulatedBackend()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deployed, err := verifier.DeployEVMVerifier(d.backend, d.auth, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContractDeployment{
+		Address:         deployed.Address.Hex(),
+		TransactionHash: deployed.TransactionHash.Hex(),
+	}, nil
+}
@@ -0,0 +1,43 @@
+#!/bin/bash
+
+#
+# Copyright 2017-2022 Provide Technologies Inc.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+
+# Installs a pinned static solc binary used to compile exported verifier
+# contracts for the on-chain verification tests.
+
+set -e
+
+if [[ -z "${SOLC_VERSION}" ]]; then
+  SOLC_VERSION=0.8.17
+fi
+
+if [[ -z "${SOLC_PATH}" ]]; then
+  SOLC_PATH=/usr/local/bin/solc
+fi
+
+if [ -x "$SOLC_PATH" ] && "$SOLC_PATH" --version | grep -q "$SOLC_VERSION"; then
+  echo "solc $SOLC_VERSION already installed at $SOLC_PATH"
+  exit 0
+fi
+
+curl -sSfL -o "$SOLC_PATH" "https://github.com/ethereum/solidity/releases/download/v${SOLC_VERSION}/solc-static-linux"
+if [[ -n "${SOLC_SHA256}" ]]; then
+  echo "${SOLC_SHA256}  ${SOLC_PATH}" | sha256sum -c -
+fi
+chmod +x "$SOLC_PATH"
+
+"$SOLC_PATH" --version
@@ -0,0 +1,26 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+ALTER TABLE ONLY provers ADD COLUMN rollup_prover_id uuid;
+
+CREATE TABLE pending_notes (
+    id SERIAL PRIMARY KEY,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    prover_id uuid NOT NULL,
+    data bytea NOT NULL
+);
+
+CREATE INDEX idx_pending_notes_prover_id ON pending_notes USING btree (prover_id);
@@ -0,0 +1,32 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+CREATE TABLE proofs (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    prover_id uuid NOT NULL,
+    status text NOT NULL DEFAULT 'pending'::text,
+    description text,
+    proof text,
+    attempts integer NOT NULL DEFAULT 0,
+    completed_at timestamp with time zone
+);
+
+ALTER TABLE ONLY proofs ADD CONSTRAINT proofs_pkey PRIMARY KEY (id);
+
+CREATE INDEX idx_proofs_prover_id ON proofs USING btree (prover_id);
+CREATE INDEX idx_proofs_status ON proofs USING btree (status);
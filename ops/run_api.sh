@@ -129,6 +129,14 @@ if [[ -z "${NATS_FORCE_TLS}" ]]; then
   NATS_FORCE_TLS=false
 fi
 
+if [[ -z "${PROVER_WORKERS}" ]]; then
+  PROVER_WORKERS=2
+fi
+
+if [[ -z "${PROVER_QUEUE_DEPTH}" ]]; then
+  PROVER_QUEUE_DEPTH=32
+fi
+
 if [[ -z "${REDIS_HOSTS}" ]]; then
   REDIS_HOSTS=localhost:6379
 fi
@@ -159,8 +167,13 @@ VAULT_API_HOST=${VAULT_API_HOST} \
 VAULT_API_SCHEME=${VAULT_API_SCHEME} \
 LOG_LEVEL=$LOG_LEVEL \
 PORT=$PORT \
+PROVER_WORKERS=$PROVER_WORKERS \
+PROVER_QUEUE_DEPTH=$PROVER_QUEUE_DEPTH \
 REDIS_HOSTS=$REDIS_HOSTS \
 REDIS_DB_INDEX=$REDIS_DB_INDEX \
 REQUIRE_TLS=$REQUIRE_TLS \
 SYSLOG_ENDPOINT=${SYSLOG_ENDPOINT} \
+OTEL_TRACES_EXPORTER=${OTEL_TRACES_EXPORTER} \
+OTEL_EXPORTER_OTLP_ENDPOINT=${OTEL_EXPORTER_OTLP_ENDPOINT} \
+OTEL_SERVICE_NAME=${OTEL_SERVICE_NAME} \
 ./.bin/api
@@ -84,6 +84,10 @@ if [[ -z "${NATS_FORCE_TLS}" ]]; then
   NATS_FORCE_TLS=false
 fi
 
+if [[ -z "${PROVER_ROLLUP_INTERVAL}" ]]; then
+  PROVER_ROLLUP_INTERVAL=1m
+fi
+
 #NATS_ROOT_CA_CERTIFICATES=/Users/kt/selfsigned-ca/ca.pem \
 #NATS_TLS_CERTIFICATES='{"/Users/kt/selfsigned-ca/peer.key": "/Users/kt/selfsigned-ca/peer.crt"}' \
 
@@ -95,6 +99,18 @@ if [[ -z "${REDIS_DB_INDEX}" ]]; then
   REDIS_DB_INDEX=1
 fi
 
+if [[ -z "${PROVER_WORKERS}" ]]; then
+  PROVER_WORKERS=2
+fi
+
+if [[ -z "${SHUTDOWN_TIMEOUT}" ]]; then
+  SHUTDOWN_TIMEOUT=1m
+fi
+
+if [[ -z "${CONSUMER_HEALTH_LISTEN_ADDR}" ]]; then
+  CONSUMER_HEALTH_LISTEN_ADDR=0.0.0.0:8086
+fi
+
 if [[ -z "${REDIS_LOG_LEVEL}" ]]; then
   REDIS_LOG_LEVEL=info
 fi
@@ -118,7 +134,14 @@ IDENT_API_SCHEME=${IDENT_API_SCHEME} \
 VAULT_API_HOST=${VAULT_API_HOST} \
 VAULT_API_SCHEME=${VAULT_API_SCHEME} \
 LOG_LEVEL=$LOG_LEVEL \
+PROVER_ROLLUP_INTERVAL=$PROVER_ROLLUP_INTERVAL \
+PROVER_WORKERS=$PROVER_WORKERS \
+SHUTDOWN_TIMEOUT=$SHUTDOWN_TIMEOUT \
+CONSUMER_HEALTH_LISTEN_ADDR=$CONSUMER_HEALTH_LISTEN_ADDR \
 REDIS_HOSTS=$REDIS_HOSTS \
 REDIS_DB_INDEX=$REDIS_DB_INDEX \
 SYSLOG_ENDPOINT=${SYSLOG_ENDPOINT} \
+OTEL_TRACES_EXPORTER=${OTEL_TRACES_EXPORTER} \
+OTEL_EXPORTER_OTLP_ENDPOINT=${OTEL_EXPORTER_OTLP_ENDPOINT} \
+OTEL_SERVICE_NAME=${OTEL_SERVICE_NAME} \
 ./.bin/consumer
@@ -6,7 +6,7 @@ function cleanup {
 }
 trap cleanup EXIT
 
-docker build -t privacy-under-test .
+docker build --build-arg INSTALL_SOLC=true -t privacy-under-test .
 docker build -f ./test/Dockerfile.integration -t privacy-integration .
 
 docker compose -f ./ops/docker-compose.yml up -d --force-recreate
@@ -27,17 +27,31 @@ import (
 	uuid "github.com/kthomas/go.uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/provideplatform/privacy/common"
+	provide "github.com/provideplatform/provide-go/api"
 )
 
 const defaultNatsStream = "privacy"
 
+const natsProverCompileCompleteSubject = "privacy.prover.compile.complete"
+const natsProverCompileFailedSubject = "privacy.prover.compile.failed"
+
+const natsCreatedProverCompileSubject = "privacy.prover.compile.pending"
+const natsCreatedProverCompileMaxInFlight = 32
+const createProverCompileAckWait = time.Minute * 30
+const createProverCompileMaxDeliveries = 5
+
 const natsProverSetupCompleteSubject = "privacy.prover.setup.complete"
 const natsProverSetupFailedSubject = "privacy.prover.setup.failed"
 
+// natsProverSetupDeadLetterSubject receives setup jobs which failed terminally or exhausted their retries
+const natsProverSetupDeadLetterSubject = "privacy.prover.setup.dead"
+
 const natsCreatedProverSetupSubject = "privacy.prover.setup.pending"
 const natsCreatedProverSetupMaxInFlight = 32
 const createProverAckWait = time.Hour * 1
 const createProverMaxDeliveries = 5
+const createProverRetryBackoff = time.Second * 5
+const createProverMaxRetryBackoff = time.Minute
 
 func init() {
 	if !common.ConsumeNATSStreamingSubscriptions {
@@ -52,22 +66,47 @@ func init() {
 
 	var waitGroup sync.WaitGroup
 
+	createNatsProverCompileSubscriptions(&waitGroup)
 	createNatsProverSetupSubscriptions(&waitGroup)
+	createNatsProverProveSubscriptions(&waitGroup)
+	createNatsProverRollupSubscriptions(&waitGroup)
+
+	go scheduleRollups()
+}
+
+func createNatsProverCompileSubscriptions(wg *sync.WaitGroup) {
+	for i := uint64(0); i < natsutil.GetNatsConsumerConcurrency(); i++ {
+		requireNatsSubscription(natsCreatedProverCompileSubject, func() (*nats.Subscription, error) {
+			return natsutil.RequireNatsJetstreamSubscription(wg,
+				createProverCompileAckWait,
+				natsCreatedProverCompileSubject,
+				natsCreatedProverCompileSubject,
+				natsCreatedProverCompileSubject,
+				drainable(consumeProverCompileMsg),
+				createProverCompileAckWait,
+				natsCreatedProverCompileMaxInFlight,
+				createProverCompileMaxDeliveries,
+				nil,
+			)
+		})
+	}
 }
 
 func createNatsProverSetupSubscriptions(wg *sync.WaitGroup) {
 	for i := uint64(0); i < natsutil.GetNatsConsumerConcurrency(); i++ {
-		natsutil.RequireNatsJetstreamSubscription(wg,
-			createProverAckWait,
-			natsCreatedProverSetupSubject,
-			natsCreatedProverSetupSubject,
-			natsCreatedProverSetupSubject,
-			consumeProverSetupMsg,
-			createProverAckWait,
-			natsCreatedProverSetupMaxInFlight,
-			createProverMaxDeliveries,
-			nil,
-		)
+		requireNatsSubscription(natsCreatedProverSetupSubject, func() (*nats.Subscription, error) {
+			return natsutil.RequireNatsJetstreamSubscription(wg,
+				createProverAckWait,
+				natsCreatedProverSetupSubject,
+				natsCreatedProverSetupSubject,
+				natsCreatedProverSetupSubject,
+				drainable(consumeProverSetupMsg),
+				createProverAckWait,
+				natsCreatedProverSetupMaxInFlight,
+				createProverMaxDeliveries,
+				nil,
+			)
+		})
 	}
 }
 
@@ -107,21 +146,158 @@ func consumeProverSetupMsg(msg *nats.Msg) {
 		return
 	}
 
-	err = prover.enrich()
-	if err != nil {
-		common.Log.Warningf("failed to enrich prover; %s", err.Error())
+	if !prover.setupRequired() {
+		common.Log.Debugf("skipping setup of prover %s with status %s", prover.ID, *prover.Status)
+		msg.Ack()
+		return
 	}
 
-	if prover.setup(db) {
+	if onChainCheck, onChainCheckOk := params["on_chain_check"].(map[string]interface{}); onChainCheckOk {
+		prover.onChainCheck = onChainCheck
+	}
+
+	ctx, span := common.StartSpan(common.NatsTraceContext(msg), "prover setup", prover.spanAttributes()...)
+	defer span.End()
+
+	err = prover.enrich(ctx)
+	if err != nil {
+		common.Log.Warningf("failed to enrich prover; %s", err.Error())
+		prover.Errors = append(prover.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("failed to enrich prover %s; %s", prover.ID, err.Error())),
+		})
+		prover.setupRetryable = true
+	} else if prover.setup(ctx, db) {
 		common.Log.Debugf("setup completed for prover: %s", prover.ID)
-		prover.updateStatus(db, proverStatusProvisioned, nil)
-		natsutil.NatsJetstreamPublish(natsProverSetupCompleteSubject, msg.Data)
+		common.NatsJetstreamPublish(ctx, natsProverSetupCompleteSubject, msg.Data)
 		msg.Ack()
-	} else {
-		common.Log.Warningf("setup failed for prover: %s", prover.ID)
-		err = fmt.Errorf("unspecified error")
-		prover.updateStatus(db, proverStatusFailed, common.StringOrNil(err.Error()))
-		natsutil.NatsJetstreamPublish(natsProverSetupFailedSubject, msg.Data)
+		return
+	}
+
+	description := prover.errorsDescription()
+	if description == nil {
+		description = common.StringOrNil("unspecified error")
+	}
+
+	attempt := uint64(1)
+	if metadata, err := msg.Metadata(); err == nil {
+		attempt = metadata.NumDelivered
+	}
+
+	if prover.setupRetryable && attempt < createProverMaxDeliveries {
+		backoff := setupRetryBackoff(attempt)
+		common.Log.Warningf("setup failed for prover: %s; attempt %d; retrying in %s; %s", prover.ID, attempt, backoff, *description)
+		prover.updateStatus(db, proverStatusPendingSetup, description)
+
+		nakWithDelay(msg, backoff)
+		return
+	}
+
+	common.Log.Warningf("setup failed for prover: %s; attempt %d; %s", prover.ID, attempt, *description)
+	prover.updateStatus(db, proverStatusFailed, description)
+
+	params["errors"] = prover.Errors
+	params["description"] = *description
+	params["attempts"] = attempt
+	params["retryable"] = prover.setupRetryable
+	payload, _ := json.Marshal(params)
+
+	common.NatsJetstreamPublish(ctx, natsProverSetupFailedSubject, payload)
+	common.NatsJetstreamPublish(ctx, natsProverSetupDeadLetterSubject, payload)
+	msg.Term()
+}
+
+// setupRetryBackoff returns the exponential backoff before the given failed setup attempt is retried
+func setupRetryBackoff(attempt uint64) time.Duration {
+	backoff := createProverRetryBackoff
+	for i := uint64(1); i < attempt && backoff < createProverMaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > createProverMaxRetryBackoff {
+		return createProverMaxRetryBackoff
+	}
+
+	return backoff
+}
+
+func consumeProverCompileMsg(msg *nats.Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			common.Log.Warningf("recovered during prover compilation; %s", r)
+			msg.Nak()
+		}
+	}()
+
+	common.Log.Debugf("consuming %d-byte NATS prover compile message on subject: %s", len(msg.Data), msg.Subject)
+
+	params := map[string]interface{}{}
+	err := json.Unmarshal(msg.Data, &params)
+	if err != nil {
+		common.Log.Warningf("failed to unmarshal prover compile message; %s", err.Error())
+		msg.Term()
+		return
+	}
+
+	proverID, proverIDOk := params["prover_id"].(string)
+	if !proverIDOk {
+		common.Log.Warning("failed to unmarshal prover_id during compile message handler")
+		msg.Term()
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+
+	prover := &Prover{}
+	db.Where("id = ?", proverID).Find(&prover)
+
+	if prover == nil || prover.ID == uuid.Nil {
+		common.Log.Warningf("failed to resolve prover during async compilation; prover id: %s", proverID)
 		msg.Nak()
+		return
+	}
+
+	compiled := prover.Status != nil && (*prover.Status == proverStatusCompiled || *prover.Status == proverStatusPendingSetup)
+	if prover.Status != nil && *prover.Status != proverStatusInit && *prover.Status != proverStatusCompiling && !compiled {
+		common.Log.Debugf("skipping compilation of prover %s with status %s", prover.ID, *prover.Status)
+		msg.Ack()
+		return
+	}
+
+	if onChainCheck, onChainCheckOk := params["on_chain_check"].(map[string]interface{}); onChainCheckOk {
+		prover.onChainCheck = onChainCheck
+	}
+
+	ctx, span := common.StartSpan(common.NatsTraceContext(msg), "prover compile", prover.spanAttributes()...)
+	defer span.End()
+
+	// compilation is deterministic, so a failed compilation is not redelivered; a redelivered
+	// message for a compiled prover only requests its setup
+	if !compiled {
+		if !prover.compile(db, prover.compileVariables()) {
+			err = fmt.Errorf("unspecified error")
+			if len(prover.Errors) > 0 && prover.Errors[0].Message != nil {
+				err = fmt.Errorf("%s", *prover.Errors[0].Message)
+			}
+
+			common.Log.Warningf("compilation failed for prover: %s; %s", prover.ID, err.Error())
+			prover.updateStatus(db, proverStatusFailed, common.StringOrNil(err.Error()))
+			common.NatsJetstreamPublish(ctx, natsProverCompileFailedSubject, msg.Data)
+			msg.Term()
+			return
+		}
+
+		common.Log.Debugf("compilation completed for prover: %s", prover.ID)
+		common.NatsJetstreamPublish(ctx, natsProverCompileCompleteSubject, msg.Data)
 	}
+
+	if prover.setupRequired() {
+		err = prover.requestSetup(ctx, db)
+		if err != nil {
+			common.Log.Warningf("failed to request setup for prover: %s; %s", prover.ID, err.Error())
+			msg.Nak()
+			return
+		}
+	}
+
+	msg.Ack()
 }
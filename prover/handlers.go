@@ -20,19 +20,26 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	dbconf "github.com/kthomas/go-db-config"
 	uuid "github.com/kthomas/go.uuid"
 	"github.com/provideplatform/privacy/common"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
 	"github.com/provideplatform/provide-go/api"
 	"github.com/provideplatform/provide-go/api/privacy"
 	provide "github.com/provideplatform/provide-go/common"
 	"github.com/provideplatform/provide-go/common/util"
 )
 
+// maxBatchVerifyProofs is the maximum number of proofs which may be verified in a single batch
+const maxBatchVerifyProofs = 10000
+
 func resolveProversQuery(db *gorm.DB, proverID, orgID, appID, userID *uuid.UUID) *gorm.DB {
 	query := db.Select("provers.*")
 	if proverID != nil {
@@ -55,14 +62,22 @@ func InstallAPI(r *gin.Engine) {
 	r.GET("/api/v1/provers", listProversHandler)
 	r.POST("/api/v1/provers", createProverHandler)
 	r.GET("/api/v1/provers/:id", proverDetailsHandler)
+	r.POST("/api/v1/provers/:id/setup", setupProverHandler)
+
+	r.GET("/api/v1/provers/:id/schema", proverWitnessSchemaHandler)
+	r.GET("/api/v1/schemas/:identifier", libraryWitnessSchemaHandler)
 
 	r.POST("/api/v1/provers/:id/prove", proveProverHandler)
-	// r.GET("/api/v1/provers/:id/prove/:proofId", proofDetailsHandler)
+	r.POST("/api/v1/provers/:id/compose", composeProverHandler)
+	r.GET("/api/v1/provers/:id/prove/:proofId", proofDetailsHandler)
 
+	r.POST("/api/v1/provers/:id/solve", solveProverHandler)
 	r.POST("/api/v1/provers/:id/verify", verifyProverHandler)
+	r.POST("/api/v1/provers/:id/verify/batch", batchVerifyProverHandler)
 	// r.GET("/api/v1/provers/:id/verify/:verifyId", proofDetailsHandler)
 
 	r.GET("/api/v1/provers/:id/notes/:index", proverNoteStoreValueHandler)
+	r.GET("/api/v1/provers/:id/notes/:index/proof", proverNoteMembershipProofHandler)
 	r.GET("/api/v1/provers/:id/nullifiers/:index", proverNullifierStoreValueHandler)
 }
 
@@ -134,10 +149,24 @@ func createProverHandler(c *gin.Context) {
 		}
 	}
 
+	// verifier contract deployment details are only ever populated by the configured deployer
+	prover.VerifierContractAddress = nil
+	prover.VerifierContractTransactionHash = nil
+
+	if onChainCheck, onChainCheckOk := params["on_chain_check"].(map[string]interface{}); onChainCheckOk {
+		prover.onChainCheck = onChainCheck
+	}
+
 	variables := params["variables"]
+	isImport := prover.Artifacts != nil
 
-	if prover.Create(variables) {
-		provide.Render(prover, 201, c)
+	if prover.Create(c.Request.Context(), variables) {
+		if isImport {
+			provide.Render(prover, 201, c)
+		} else {
+			// compilation and setup are completed asynchronously
+			provide.Render(prover, 202, c)
+		}
 	} else {
 		obj := map[string]interface{}{}
 		obj["errors"] = prover.Errors
@@ -145,6 +174,81 @@ func createProverHandler(c *gin.Context) {
 	}
 }
 
+// retry the setup of a prover for which setup failed
+func setupProverHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	buf, err := c.GetRawData()
+	if err != nil {
+		provide.RenderError(err.Error(), 400, c)
+		return
+	}
+
+	params := map[string]interface{}{}
+	if len(buf) > 0 {
+		err = json.Unmarshal(buf, &params)
+		if err != nil {
+			provide.RenderError(err.Error(), 422, c)
+			return
+		}
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	if prover.VerifyOnly {
+		provide.RenderError("setup not supported for verify-only prover", 422, c)
+		return
+	} else if prover.Binary == nil || len(prover.Binary) == 0 {
+		provide.RenderError("setup requires a compiled prover", 422, c)
+		return
+	} else if prover.Status != nil && *prover.Status != proverStatusFailed && *prover.Status != proverStatusCompiled && *prover.Status != proverStatusPendingSetup {
+		provide.RenderError(fmt.Sprintf("setup cannot be retried for prover with status %s", *prover.Status), 409, c)
+		return
+	}
+
+	if onChainCheck, onChainCheckOk := params["on_chain_check"].(map[string]interface{}); onChainCheckOk {
+		prover.onChainCheck = onChainCheck
+	}
+
+	err = prover.requestSetup(c.Request.Context(), db)
+	if err != nil {
+		provide.RenderError(err.Error(), 500, c)
+		return
+	}
+
+	provide.Render(prover, 202, c)
+}
+
 // fetch prover details
 func proverDetailsHandler(c *gin.Context) {
 	appID := util.AuthorizedSubjectID(c, "application")
@@ -182,10 +286,96 @@ func proverDetailsHandler(c *gin.Context) {
 		return
 	}
 
-	prover.enrich()
+	prover.enrich(c.Request.Context())
 	provide.Render(prover, 200, c)
 }
 
+// describe the witness expected by a prover
+func proverWitnessSchemaHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	schema, err := prover.WitnessSchema()
+	if err != nil {
+		provide.RenderError(err.Error(), 422, c)
+		return
+	}
+
+	provide.Render(schema, 200, c)
+}
+
+// describe the witness expected by a library prover; variables may be provided
+// as query params, i.e., ?Values_count=3
+func libraryWitnessSchemaHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	prover := &Prover{
+		Identifier:    common.StringOrNil(c.Param("identifier")),
+		Provider:      common.StringOrNil(c.DefaultQuery("provider", zkp.ZKSnarkProverProviderGnark)),
+		ProvingScheme: common.StringOrNil(c.DefaultQuery("proving_scheme", proverProvingSchemeGroth16)),
+		Curve:         common.StringOrNil(c.DefaultQuery("curve", ecc.BN254.String())),
+	}
+
+	provider := prover.proverProviderFactory()
+	if provider == nil {
+		provide.RenderError(fmt.Sprintf("unknown or unsupported provider: %s", *prover.Provider), 422, c)
+		return
+	}
+
+	variables := map[string]interface{}{}
+	for key, vals := range c.Request.URL.Query() {
+		if strings.HasSuffix(key, "_count") && len(vals) > 0 {
+			variables[key] = vals[0]
+		}
+	}
+
+	schema, err := provider.WitnessSchema(*prover.Identifier, variables)
+	if err != nil {
+		provide.RenderError(err.Error(), 404, c)
+		return
+	}
+
+	provide.Render(schema, 200, c)
+}
+
 // generate a proof
 func proveProverHandler(c *gin.Context) {
 	appID := util.AuthorizedSubjectID(c, "application")
@@ -235,18 +425,37 @@ func proveProverHandler(c *gin.Context) {
 		return
 	}
 
-	witness, witnessOk := params["witness"].(map[string]interface{}) // FIXME-- support string witness also?
+	// witness may be provided as a (nested) object or as a hex-encoded serialized gnark witness
+	witness, witnessOk := witnessParam(params)
 	if !witnessOk {
 		provide.RenderError("witness required for proof generation", 422, c)
 		return
 	}
 
-	proof, err := prover.Prove(witness)
+	// async proofs are generated by the proving workers of the consumer
+	if async, asyncOk := params["async"].(bool); asyncOk && async {
+		proof, err := prover.proveAsync(c.Request.Context(), db, witness)
+		if err != nil {
+			provide.RenderError(err.Error(), 422, c)
+			return
+		}
+
+		provide.Render(proof, 202, c)
+		return
+	}
+
+	ctx, release, ok := acquireProvingWorker(c, prover)
+	if !ok {
+		return
+	}
+	defer release()
+
+	proof, err := prover.Prove(ctx, witness)
 	if err != nil {
 		provide.Render(&privacy.ProveResponse{
 			Errors: []*api.Error{{Message: common.StringOrNil(err.Error())}},
 			Proof:  nil,
-		}, 422, c)
+		}, errorStatus(err), c)
 		return
 	}
 
@@ -255,6 +464,252 @@ func proveProverHandler(c *gin.Context) {
 	}, 200, c)
 }
 
+// fetch the details of an async proof
+func proofDetailsHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	proofID, err := uuid.FromString(c.Param("proofId"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	proof := &Proof{}
+	db.Where("id = ? AND prover_id = ?", proofID, prover.ID).Find(&proof)
+	if proof == nil || proof.ID == uuid.Nil {
+		provide.RenderError("proof not found", 404, c)
+		return
+	}
+
+	provide.Render(proof, 200, c)
+}
+
+// compose a proof which recursively verifies the proof of an inner prover; the inner proof
+// is referenced by the id of the completed proof record of the inner prover
+func composeProverHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	buf, err := c.GetRawData()
+	if err != nil {
+		provide.RenderError(err.Error(), 400, c)
+		return
+	}
+
+	var params map[string]interface{}
+	err = json.Unmarshal(buf, &params)
+	if err != nil {
+		provide.RenderError(err.Error(), 422, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	innerProverID, err := uuid.FromString(fmt.Sprintf("%v", params["inner_prover_id"]))
+	if err != nil {
+		provide.RenderError("inner_prover_id required for proof composition", 422, c)
+		return
+	}
+
+	innerProofID, err := uuid.FromString(fmt.Sprintf("%v", params["inner_proof_id"]))
+	if err != nil {
+		provide.RenderError("inner_proof_id required for proof composition", 422, c)
+		return
+	}
+
+	inner := &Prover{}
+	resolveProversQuery(db, &innerProverID, orgID, appID, userID).Find(&inner)
+	if inner == nil || inner.ID == uuid.Nil {
+		provide.RenderError("inner prover not found", 404, c)
+		return
+	} else if inner.ApplicationID != nil && appID != nil && inner.ApplicationID.String() != appID.String() {
+		provide.RenderError("inner prover not found", 404, c)
+		return
+	} else if appID != nil && inner.ApplicationID == nil {
+		provide.RenderError("inner prover not found", 404, c)
+		return
+	} else if inner.OrganizationID != nil && orgID != nil && inner.OrganizationID.String() != orgID.String() {
+		provide.RenderError("inner prover not found", 404, c)
+		return
+	} else if orgID != nil && inner.OrganizationID == nil {
+		provide.RenderError("inner prover not found", 404, c)
+		return
+	}
+
+	innerProof := &Proof{}
+	db.Where("id = ? AND prover_id = ?", innerProofID, inner.ID).Find(&innerProof)
+	if innerProof == nil || innerProof.ID == uuid.Nil {
+		provide.RenderError("inner proof not found", 404, c)
+		return
+	}
+
+	// witness provides the inputs of the outer circuit which are not derived from the inner proof
+	witness, witnessOk := witnessParam(params)
+	if !witnessOk {
+		provide.RenderError("witness required for proof composition", 422, c)
+		return
+	}
+
+	ctx, release, ok := acquireProvingWorker(c, prover)
+	if !ok {
+		return
+	}
+	defer release()
+
+	proof, err := prover.Compose(ctx, inner, innerProof, witness)
+	if err != nil {
+		provide.Render(&privacy.ProveResponse{
+			Errors: []*api.Error{{Message: common.StringOrNil(err.Error())}},
+			Proof:  nil,
+		}, errorStatus(err), c)
+		return
+	}
+
+	provide.Render(map[string]interface{}{
+		"proof":           proof,
+		"inner_prover_id": inner.ID,
+		"inner_proof_id":  innerProof.ID,
+	}, 200, c)
+}
+
+// solve a witness against the prover constraint system without generating a proof
+func solveProverHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	buf, err := c.GetRawData()
+	if err != nil {
+		provide.RenderError(err.Error(), 400, c)
+		return
+	}
+
+	var params map[string]interface{}
+	err = json.Unmarshal(buf, &params)
+	if err != nil {
+		provide.RenderError(err.Error(), 422, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	witness, witnessOk := witnessParam(params)
+	if !witnessOk {
+		provide.RenderError("witness required for solving", 422, c)
+		return
+	}
+
+	err = prover.Solve(witness)
+	if err != nil {
+		if constraintErr, constraintErrOk := err.(*zkp.ConstraintError); constraintErrOk {
+			provide.Render(map[string]interface{}{
+				"solved":     false,
+				"constraint": constraintErr,
+			}, 200, c)
+			return
+		}
+
+		provide.Render(map[string]interface{}{
+			"solved": false,
+			"errors": []*api.Error{{Message: common.StringOrNil(err.Error())}},
+		}, 422, c)
+		return
+	}
+
+	provide.Render(map[string]interface{}{
+		"solved": true,
+	}, 200, c)
+}
+
 // verify a proof
 func verifyProverHandler(c *gin.Context) {
 	appID := util.AuthorizedSubjectID(c, "application")
@@ -310,7 +765,8 @@ func verifyProverHandler(c *gin.Context) {
 		return
 	}
 
-	witness, witnessOk := params["witness"].(map[string]interface{})
+	// witness may be provided as a (nested) object or as a hex-encoded serialized public gnark witness
+	witness, witnessOk := witnessParam(params)
 	if !witnessOk {
 		provide.RenderError("witness required for verification", 422, c)
 		return
@@ -321,12 +777,12 @@ func verifyProverHandler(c *gin.Context) {
 		store = _store
 	}
 
-	result, err := prover.Verify(proof, witness, store)
+	result, err := prover.Verify(c.Request.Context(), proof, witness, store)
 	if err != nil {
 		provide.Render(&privacy.VerificationResponse{
 			Errors: []*api.Error{{Message: common.StringOrNil(err.Error())}},
 			Result: false,
-		}, 422, c)
+		}, errorStatus(err), c)
 		return
 	}
 
@@ -335,6 +791,109 @@ func verifyProverHandler(c *gin.Context) {
 	}, 200, c)
 }
 
+// verify many proofs against the same prover
+func batchVerifyProverHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	buf, err := c.GetRawData()
+	if err != nil {
+		provide.RenderError(err.Error(), 400, c)
+		return
+	}
+
+	var params map[string]interface{}
+	err = json.Unmarshal(buf, &params)
+	if err != nil {
+		provide.RenderError(err.Error(), 422, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	// proofs is a list of {"proof": "...", "witness": {...}} objects
+	items, itemsOk := params["proofs"].([]interface{})
+	if !itemsOk || len(items) == 0 {
+		provide.RenderError("proofs required for batch verification", 422, c)
+		return
+	} else if len(items) > maxBatchVerifyProofs {
+		provide.RenderError(fmt.Sprintf("at most %d proofs may be verified in a batch", maxBatchVerifyProofs), 422, c)
+		return
+	}
+
+	proofs := make([]string, len(items))
+	witnesses := make([]interface{}, len(items))
+	for i, item := range items {
+		_item, _ := item.(map[string]interface{})
+
+		proof, proofOk := _item["proof"].(string)
+		if !proofOk {
+			provide.RenderError(fmt.Sprintf("proof required for verification of item %d", i), 422, c)
+			return
+		}
+
+		// witness may be provided as a (nested) object or as a hex-encoded serialized public gnark witness
+		witness, witnessOk := witnessParam(_item)
+		if !witnessOk {
+			provide.RenderError(fmt.Sprintf("witness required for verification of item %d", i), 422, c)
+			return
+		}
+
+		proofs[i] = proof
+		witnesses[i] = witness
+	}
+
+	errs, err := prover.BatchVerify(proofs, witnesses)
+	if err != nil {
+		provide.RenderError(err.Error(), 422, c)
+		return
+	}
+
+	results := make([]*privacy.VerificationResponse, len(errs))
+	for i, err := range errs {
+		results[i] = &privacy.VerificationResponse{
+			Result: err == nil,
+		}
+
+		if err != nil {
+			results[i].Errors = []*api.Error{{Message: common.StringOrNil(err.Error())}}
+		}
+	}
+
+	provide.Render(map[string]interface{}{
+		"results": results,
+	}, 200, c)
+}
+
 // prover note store value hanbdler
 func proverNoteStoreValueHandler(c *gin.Context) {
 	appID := util.AuthorizedSubjectID(c, "application")
@@ -398,6 +957,64 @@ func proverNoteStoreValueHandler(c *gin.Context) {
 	}, 200, c)
 }
 
+// prover note membership proof handler; the returned witness can be used with the note membership prover
+func proverNoteMembershipProofHandler(c *gin.Context) {
+	appID := util.AuthorizedSubjectID(c, "application")
+	orgID := util.AuthorizedSubjectID(c, "organization")
+	userID := util.AuthorizedSubjectID(c, "user")
+	if appID == nil && orgID == nil && userID == nil {
+		provide.RenderError("unauthorized", 401, c)
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+	proverID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		provide.RenderError("bad request", 400, c)
+		return
+	}
+
+	prover := &Prover{}
+	resolveProversQuery(db, &proverID, orgID, appID, userID).Find(&prover)
+
+	if prover == nil || prover.ID == uuid.Nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.ApplicationID != nil && appID != nil && prover.ApplicationID.String() != appID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if appID != nil && prover.ApplicationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if prover.OrganizationID != nil && orgID != nil && prover.OrganizationID.String() != orgID.String() {
+		provide.RenderError("prover not found", 404, c)
+		return
+	} else if orgID != nil && prover.OrganizationID == nil {
+		provide.RenderError("prover not found", 404, c)
+		return
+	}
+
+	index, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		provide.RenderError(err.Error(), 400, c)
+		return
+	}
+
+	proof, err := prover.NoteMembershipProof(index)
+	if err != nil {
+		common.Log.Warningf("failed to resolve note membership proof at index: %d; %s", index, err.Error())
+		provide.RenderError(err.Error(), 404, c)
+		return
+	}
+
+	provide.Render(map[string]interface{}{
+		"root":       hex.EncodeToString(proof.Root),
+		"commitment": hex.EncodeToString(proof.Commitment),
+		"index":      proof.Index,
+		"witness":    proof.Witness(),
+	}, 200, c)
+}
+
 // prover proof store value hanbdler
 func proverNullifierStoreValueHandler(c *gin.Context) {
 	appID := util.AuthorizedSubjectID(c, "application")
@@ -459,3 +1076,28 @@ func proverNullifierStoreValueHandler(c *gin.Context) {
 		"value": base64.StdEncoding.EncodeToString(value),
 	}, 200, c)
 }
+
+// errorStatus returns the response status for the given prove or verify error; canceled
+// requests, i.e. on client disconnect, are distinguished from exceeding the max proving duration
+func errorStatus(err error) int {
+	switch err {
+	case zkp.ErrCanceled:
+		return 499
+	case zkp.ErrDeadlineExceeded:
+		return 504
+	}
+
+	return 422
+}
+
+// witnessParam resolves the witness param as an object or as a hex-encoded serialized witness
+func witnessParam(params map[string]interface{}) (interface{}, bool) {
+	switch witness := params["witness"].(type) {
+	case map[string]interface{}:
+		return witness, true
+	case string:
+		return witness, witness != ""
+	}
+
+	return nil, false
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prover
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/provideplatform/privacy/common"
+)
+
+const metricsNamespace = "privacy"
+const metricsSubsystem = "prover"
+
+const metricsStoreNote = "note"
+const metricsStoreNullifier = "nullifier"
+
+// metricsDurationBuckets range from 5ms to ~12m, covering proving and verification of small
+// circuits through setup of large circuits
+var metricsDurationBuckets = prometheus.ExponentialBuckets(0.005, 2.5, 14)
+
+var metricsProverLabels = []string{"identifier", "curve", "scheme"}
+
+var (
+	compileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "compile_duration_seconds",
+		Help:      "Duration of prover circuit compilation",
+		Buckets:   metricsDurationBuckets,
+	}, metricsProverLabels)
+
+	setupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "setup_duration_seconds",
+		Help:      "Duration of prover setup, i.e. proving and verifying key generation",
+		Buckets:   metricsDurationBuckets,
+	}, metricsProverLabels)
+
+	proveDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "prove_duration_seconds",
+		Help:      "Duration of proof generation",
+		Buckets:   metricsDurationBuckets,
+	}, metricsProverLabels)
+
+	verifyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "verify_duration_seconds",
+		Help:      "Duration of proof verification",
+		Buckets:   metricsDurationBuckets,
+	}, metricsProverLabels)
+
+	verificationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "verification_failures_total",
+		Help:      "Number of proofs which failed verification",
+	}, metricsProverLabels)
+
+	doubleSpendRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "double_spend_rejections_total",
+		Help:      "Number of state updates rejected as an attempt to double-spend a note",
+	}, metricsProverLabels)
+
+	notificationPublishErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "notification_publish_errors_total",
+		Help:      "Number of prover event notifications which failed to publish",
+	}, []string{"event"})
+
+	storeSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "store_size",
+		Help:      "Number of values in the note and nullifier stores of each prover",
+	}, []string{"prover_id", "store"})
+
+	proofsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "proofs_in_flight",
+		Help:      "Number of proofs being generated",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		compileDuration,
+		setupDuration,
+		proveDuration,
+		verifyDuration,
+		verificationFailures,
+		doubleSpendRejections,
+		notificationPublishErrors,
+		storeSize,
+		proofsInFlight,
+	)
+}
+
+// metricLabels returns the identifier, curve and proving scheme label values of the prover
+func (c *Prover) metricLabels() []string {
+	labels := make([]string, 0, len(metricsProverLabels))
+	for _, val := range []*string{c.Identifier, c.Curve, c.ProvingScheme} {
+		if val == nil {
+			labels = append(labels, "")
+		} else {
+			labels = append(labels, *val)
+		}
+	}
+	return labels
+}
+
+// observeDuration records the duration elapsed since the given time in the given histogram
+func (c *Prover) observeDuration(histogram *prometheus.HistogramVec, startedAt time.Time) {
+	histogram.WithLabelValues(c.metricLabels()...).Observe(time.Since(startedAt).Seconds())
+}
+
+// observeStoreSizes records the number of values in the note and nullifier stores of the prover
+func (c *Prover) observeStoreSizes() {
+	if c.noteStore != nil {
+		size, err := c.noteStore.Size()
+		if err != nil {
+			common.Log.Debugf("failed to observe note store size for prover %s; %s", c.ID, err.Error())
+		} else {
+			storeSize.WithLabelValues(c.ID.String(), metricsStoreNote).Set(float64(size))
+		}
+	}
+
+	if c.nullifierStore != nil {
+		size, err := c.nullifierStore.Size()
+		if err != nil {
+			common.Log.Debugf("failed to observe nullifier store size for prover %s; %s", c.ID, err.Error())
+		} else {
+			storeSize.WithLabelValues(c.ID.String(), metricsStoreNullifier).Set(float64(size))
+		}
+	}
+}
@@ -17,20 +17,22 @@
 package prover
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
-	natsutil "github.com/kthomas/go-natsutil"
 	"github.com/nats-io/nats.go"
 	"github.com/provideplatform/privacy/common"
 )
 
 const natsProverNotificationNoteDeposit = "note.deposited"
 const natsProverNotificationNoteNullified = "note.nullified"
+const natsProverNotificationNoteRollup = "note.rollup"
 const natsProverNotificationExit = "exit"
+const natsProverNotificationStatus = "status"
 
-// dispatchNotification broadcasts an event to qualified subjects
-func (c *Prover) dispatchNotification(event string) (*nats.PubAck, error) {
+// dispatchNotification broadcasts an event, with optional params, to qualified subjects
+func (c *Prover) dispatchNotification(ctx context.Context, event string, params map[string]interface{}) (*nats.PubAck, error) {
 	prefix := c.notificationsSubjectPrefix()
 	if prefix == nil {
 		return nil, fmt.Errorf("failed to dispatch event notification for prover %s; nil prefix", c.ID.String())
@@ -39,8 +41,15 @@ func (c *Prover) dispatchNotification(event string) (*nats.PubAck, error) {
 		return nil, fmt.Errorf("failed to dispatch event notification for prover %s", c.ID.String())
 	}
 	subject := fmt.Sprintf("%s.%s", *prefix, event)
-	payload, _ := json.Marshal(map[string]interface{}{})
-	return natsutil.NatsJetstreamPublish(subject, payload)
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	payload, _ := json.Marshal(params)
+	ack, err := common.NatsJetstreamPublish(ctx, subject, payload)
+	if err != nil {
+		notificationPublishErrors.WithLabelValues(event).Inc()
+	}
+	return ack, err
 }
 
 // notificationsSubject returns a namespaced subject suitable for pub/sub subscriptions
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prover
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/provideplatform/privacy/common"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+	provide "github.com/provideplatform/provide-go/common"
+)
+
+// proverProvingMemoryFactor is the estimated working memory used to generate a proof, relative
+// to the size of the compiled constraint system of the prover
+const proverProvingMemoryFactor = 8
+
+// proverProvingKeyMemoryFactor is the estimated memory used by a decoded proving key, relative
+// to the size of the encoded proving key
+const proverProvingKeyMemoryFactor = 2
+
+// provingPool bounds the proofs generated concurrently by the API and the async proving workers
+var provingPool = common.NewWorkerPool(
+	common.ProvingWorkers,
+	common.ProvingQueueDepth,
+	common.ProvingConcurrencyPerProver,
+	common.ProvingMemoryLimit,
+	common.ProvingQueueTimeout,
+)
+
+// ProvingPoolStatus returns a snapshot of the proving worker pool, i.e. for the status endpoint
+func ProvingPoolStatus() *common.WorkerPoolStatus {
+	return provingPool.Status()
+}
+
+// provingCost returns the estimated memory in bytes used to generate a proof using the prover, i.e.
+// the working memory relative to the constraint system, the decoded proving key and the srs; the
+// proving key size is recorded when the keys are persisted, so the key need not be resolved
+func (c *Prover) provingCost() int64 {
+	provingKeySize := int64(len(c.provingKey))
+	if provingKeySize == 0 && c.ProvingKeySize != nil {
+		provingKeySize = *c.ProvingKeySize
+	}
+
+	return int64(len(c.Binary))*proverProvingMemoryFactor + provingKeySize*proverProvingKeyMemoryFactor + int64(len(c.srs))
+}
+
+// acquireProvingWorker acquires a proving worker for the given prover, blocking while the proof
+// is queued or until the request is canceled; if the proof is not admitted, a 429 or 503 response
+// with a Retry-After header is rendered and false is returned. The proof must be generated using
+// the returned context, such that the returned function releases the worker only once the proving
+// operations started with the context have returned, including those abandoned on cancellation
+func acquireProvingWorker(c *gin.Context, prover *Prover) (context.Context, func(), bool) {
+	release, err := provingPool.Acquire(c.Request.Context(), prover.ID.String(), prover.provingCost())
+	if err == nil {
+		ctx, release := provingContext(c.Request.Context(), release)
+		return ctx, release, true
+	}
+
+	retryAfter := int64(math.Ceil(provingPool.RetryAfter().Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	common.Log.Debugf("proof not admitted for prover %s; %s", prover.ID, err.Error())
+
+	switch err {
+	case common.ErrWorkerPoolKeyLimitExceeded:
+		provide.RenderError(fmt.Sprintf("too many concurrent proofs for prover %s", prover.ID), 429, c)
+	default:
+		provide.RenderError(fmt.Sprintf("proving capacity unavailable; %s", err.Error()), 503, c)
+	}
+
+	return nil, nil, false
+}
+
+// acquireAsyncProvingWorker acquires a proving worker for an async proof of the given prover,
+// waiting while the proof is not admitted until the context is done; async proofs are bounded
+// by the same pool as the proofs generated by the API
+func acquireAsyncProvingWorker(ctx context.Context, prover *Prover) (context.Context, func(), error) {
+	for {
+		release, err := provingPool.Acquire(ctx, prover.ID.String(), prover.provingCost())
+		if err == nil {
+			ctx, release := provingContext(ctx, release)
+			return ctx, release, nil
+		}
+
+		if err != common.ErrWorkerPoolKeyLimitExceeded && err != common.ErrWorkerPoolQueueFull && err != common.ErrWorkerPoolQueueTimeout {
+			return nil, nil, err
+		}
+
+		common.Log.Debugf("async proof not admitted for prover %s; %s", prover.ID, err.Error())
+
+		timer := time.NewTimer(provingPool.RetryAfter())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// provingContext returns a copy of the given context tracking the proving operations started with
+// it, and a function which releases the given worker once the tracked operations have returned
+func provingContext(ctx context.Context, release func()) (context.Context, func()) {
+	wg := &sync.WaitGroup{}
+	return zkp.WithOperations(ctx, wg), func() {
+		go func() {
+			wg.Wait()
+			release()
+		}()
+	}
+}
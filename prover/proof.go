@@ -0,0 +1,377 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prover
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	dbconf "github.com/kthomas/go-db-config"
+	natsutil "github.com/kthomas/go-natsutil"
+	uuid "github.com/kthomas/go.uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/provideplatform/privacy/common"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+	provide "github.com/provideplatform/provide-go/api"
+	vault "github.com/provideplatform/provide-go/api/vault"
+	util "github.com/provideplatform/provide-go/common/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const natsProverProvePendingSubject = "privacy.prover.prove.pending"
+const natsProverProveCompleteSubject = "privacy.prover.prove.complete"
+const natsProverProveFailedSubject = "privacy.prover.prove.failed"
+const natsProverProveMaxInFlight = 64
+const proverProveAckWait = time.Minute * 5
+const proverProveMaxDeliveries = 5
+
+const proofStatusPending = "pending"
+const proofStatusProving = "proving"
+const proofStatusCompleted = "completed"
+const proofStatusFailed = "failed"
+
+// Proof is the record of a proof generated asynchronously by a proving worker
+type Proof struct {
+	provide.Model
+
+	ProverID      uuid.UUID  `sql:"type:uuid" json:"prover_id"`
+	Status        *string    `sql:"not null;default:'pending'" json:"status"`
+	Description   *string    `json:"description,omitempty"`
+	Proof         *string    `json:"proof,omitempty"`
+	PublicWitness *string    `json:"public_witness,omitempty"` // hex-encoded serialized public witness of the proof
+	Witness       *string    `json:"-"`                        // hex-encoded witness encrypted using the prover encryption key; cleared once the proof is completed or failed
+	Attempts      int        `sql:"not null;default:0" json:"attempts"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+func createNatsProverProveSubscriptions(wg *sync.WaitGroup) {
+	for i := 0; i < common.ProvingWorkers; i++ {
+		requireNatsSubscription(natsProverProvePendingSubject, func() (*nats.Subscription, error) {
+			return natsutil.RequireNatsJetstreamSubscription(wg,
+				proverProveAckWait,
+				natsProverProvePendingSubject,
+				natsProverProvePendingSubject,
+				natsProverProvePendingSubject,
+				drainable(consumeProverProveMsg),
+				proverProveAckWait,
+				natsProverProveMaxInFlight,
+				proverProveMaxDeliveries,
+				nil,
+			)
+		})
+	}
+}
+
+// proveAsync creates a pending proof record for the given witness and publishes it for
+// generation by a proving worker; the witness is stored encrypted on the proof record, such
+// that only the proof and prover ids are published
+func (c *Prover) proveAsync(ctx context.Context, db *gorm.DB, witness interface{}) (*Proof, error) {
+	if c.VerifyOnly {
+		return nil, fmt.Errorf("failed to generate proof for prover %s; prover is verify-only", c.ID)
+	}
+
+	encryptedWitness, err := c.encryptWitness(witness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof for prover %s; %s", c.ID, err.Error())
+	}
+
+	proof := &Proof{
+		ProverID: c.ID,
+		Status:   common.StringOrNil(proofStatusPending),
+		Witness:  encryptedWitness,
+	}
+
+	result := db.Create(&proof)
+	if len(result.GetErrors()) > 0 {
+		return nil, result.GetErrors()[0]
+	}
+
+	_, err = common.NatsJetstreamPublish(ctx, natsProverProvePendingSubject, proof.payload())
+	if err != nil {
+		proof.updateStatus(db, proofStatusFailed, common.StringOrNil(err.Error()))
+		return nil, fmt.Errorf("failed to publish pending proof %s for prover %s; %s", proof.ID, c.ID, err.Error())
+	}
+
+	common.Log.Debugf("published pending proof %s for prover %s", proof.ID, c.ID)
+	return proof, nil
+}
+
+func consumeProverProveMsg(msg *nats.Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			common.Log.Warningf("recovered during proof generation; %s", r)
+			msg.Nak()
+		}
+	}()
+
+	common.Log.Debugf("consuming %d-byte NATS prover prove message on subject: %s", len(msg.Data), msg.Subject)
+
+	params := map[string]interface{}{}
+	err := json.Unmarshal(msg.Data, &params)
+	if err != nil {
+		common.Log.Warningf("failed to unmarshal prover prove message; %s", err.Error())
+		msg.Term()
+		return
+	}
+
+	proofID, proofIDOk := params["proof_id"].(string)
+	proverID, proverIDOk := params["prover_id"].(string)
+	if !proofIDOk || !proverIDOk {
+		common.Log.Warning("failed to unmarshal proof_id and prover_id during prove message handler")
+		msg.Term()
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+
+	proof := &Proof{}
+	db.Where("id = ? AND prover_id = ?", proofID, proverID).Find(&proof)
+	if proof == nil || proof.ID == uuid.Nil {
+		common.Log.Warningf("failed to resolve proof %s during async proof generation", proofID)
+		msg.Term()
+		return
+	}
+
+	if proof.Status != nil && (*proof.Status == proofStatusCompleted || *proof.Status == proofStatusFailed) {
+		common.Log.Debugf("skipping redelivered %s proof %s", *proof.Status, proof.ID)
+		msg.Ack()
+		return
+	}
+
+	// a proof redelivered while proving was interrupted after the proof may have been recorded in
+	// the prover state, so it is failed rather than proven again
+	if proof.Status != nil && *proof.Status == proofStatusProving {
+		common.Log.Warningf("failing interrupted async proof generation for proof %s", proof.ID)
+		proof.fail(common.NatsTraceContext(msg), db, msg, fmt.Errorf("proof generation interrupted"))
+		return
+	}
+
+	prover := &Prover{}
+	db.Where("id = ?", proverID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		common.Log.Warningf("failed to resolve prover during async proof generation; prover id: %s", proverID)
+		proof.updateStatus(db, proofStatusFailed, common.StringOrNil("prover not found"))
+		natsutil.NatsJetstreamPublish(natsProverProveFailedSubject, proof.payload())
+		msg.Term()
+		return
+	}
+
+	ctx, span := common.StartSpan(common.NatsTraceContext(msg), "prover prove async", attribute.String("proof.id", proof.ID.String()))
+	defer span.End()
+
+	proof.Attempts++
+
+	// the message is kept in progress while the proof is queued and generated, which may exceed the ack wait
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(proverProveAckWait / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				msg.InProgress()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// failures to resolve the witness, the prover artifacts or a proving worker are transient
+	witness, err := prover.decryptWitness(proof)
+	if err != nil {
+		common.Log.Warningf("failed to prepare async proof generation for proof %s of prover %s; attempt %d; %s", proof.ID, prover.ID, proof.Attempts, err.Error())
+		proof.retry(ctx, db, msg, err)
+		return
+	}
+
+	provingCtx, release, err := acquireAsyncProvingWorker(ctx, prover)
+	if err != nil {
+		common.Log.Warningf("failed to acquire proving worker for proof %s of prover %s; attempt %d; %s", proof.ID, prover.ID, proof.Attempts, err.Error())
+		proof.retry(ctx, db, msg, err)
+		return
+	}
+	defer release()
+
+	proof.updateStatus(db, proofStatusProving, nil)
+
+	// proving errors are deterministic given the witness, and the prover state may have been
+	// updated, so the proof is not retried unless the prover artifacts could not be resolved
+	_proof, err := prover.Prove(provingCtx, witness)
+	if _, ok := err.(*secretUnavailableError); ok {
+		common.Log.Warningf("failed to resolve artifacts for proof %s of prover %s; attempt %d; %s", proof.ID, prover.ID, proof.Attempts, err.Error())
+		proof.retry(ctx, db, msg, err)
+		return
+	} else if err != nil {
+		common.Log.Warningf("async proof generation failed for proof %s of prover %s; %s", proof.ID, prover.ID, err.Error())
+		proof.fail(ctx, db, msg, err)
+		return
+	}
+
+	publicWitness, err := prover.publicWitness(witness)
+	if err != nil {
+		common.Log.Warningf("failed to resolve public witness for proof %s of prover %s; %s", proof.ID, prover.ID, err.Error())
+	}
+
+	completedAt := time.Now()
+	proof.Proof = _proof
+	proof.PublicWitness = publicWitness
+	proof.CompletedAt = &completedAt
+	proof.updateStatus(db, proofStatusCompleted, nil)
+
+	common.NatsJetstreamPublish(ctx, natsProverProveCompleteSubject, proof.payload())
+
+	common.Log.Debugf("async proof generation completed for proof %s of prover %s", proof.ID, prover.ID)
+	msg.Ack()
+}
+
+// encryptWitness returns the given witness encrypted using the prover encryption key, hex-encoded
+func (c *Prover) encryptWitness(witness interface{}) (*string, error) {
+	if c.VaultID == nil || c.EncryptionKeyID == nil {
+		return nil, fmt.Errorf("failed to encrypt witness; encryption key not resolved for prover %s", c.ID)
+	}
+
+	raw, err := json.Marshal(witness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt witness; failed to marshal witness; %s", err.Error())
+	}
+
+	resp, err := vault.Encrypt(
+		util.DefaultVaultAccessJWT,
+		c.VaultID.String(),
+		c.EncryptionKeyID.String(),
+		string(raw),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt witness; %s", err.Error())
+	}
+
+	return common.StringOrNil(resp.Data), nil
+}
+
+// decryptWitness returns the witness of the given pending proof, decrypted using the prover encryption key
+func (c *Prover) decryptWitness(proof *Proof) (interface{}, error) {
+	if proof.Witness == nil {
+		return nil, fmt.Errorf("failed to decrypt witness; no witness stored for proof %s", proof.ID)
+	}
+
+	if c.VaultID == nil || c.EncryptionKeyID == nil {
+		return nil, fmt.Errorf("failed to decrypt witness; encryption key not resolved for prover %s", c.ID)
+	}
+
+	resp, err := vault.Decrypt(
+		util.DefaultVaultAccessJWT,
+		c.VaultID.String(),
+		c.EncryptionKeyID.String(),
+		map[string]interface{}{
+			"data": *proof.Witness,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt witness for proof %s; %s", proof.ID, err.Error())
+	}
+
+	var witness interface{}
+	err = json.Unmarshal([]byte(resp.Data), &witness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decrypted witness for proof %s; %s", proof.ID, err.Error())
+	}
+
+	return witness, nil
+}
+
+// publicWitness returns the hex-encoded serialized public part of the given witness, or nil
+// when the prover provider does not support serializing public witnesses
+func (c *Prover) publicWitness(witness interface{}) (*string, error) {
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider")
+	}
+
+	recursiveProvider, recursiveProviderOk := provider.(zkp.RecursiveProverProvider)
+	if !recursiveProviderOk {
+		return nil, nil
+	}
+
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	publicWitness, err := recursiveProvider.PublicWitness(witval)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.StringOrNil(hex.EncodeToString(publicWitness)), nil
+}
+
+// payload returns the message published for the proof, which identifies the proof and its prover
+func (p *Proof) payload() []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"proof_id":  p.ID.String(),
+		"prover_id": p.ProverID.String(),
+	})
+	return payload
+}
+
+// retry returns the proof to pending following a transient failure, such that the message is
+// redelivered; the proof fails once the message has been delivered the maximum number of times
+func (p *Proof) retry(ctx context.Context, db *gorm.DB, msg *nats.Msg, err error) {
+	metadata, metadataErr := msg.Metadata()
+	if metadataErr == nil && metadata.NumDelivered >= proverProveMaxDeliveries {
+		p.fail(ctx, db, msg, err)
+		return
+	}
+
+	p.updateStatus(db, proofStatusPending, common.StringOrNil(err.Error()))
+	msg.Nak()
+}
+
+// fail marks the proof failed, publishes the failure and terminates the message
+func (p *Proof) fail(ctx context.Context, db *gorm.DB, msg *nats.Msg, err error) {
+	p.updateStatus(db, proofStatusFailed, common.StringOrNil(err.Error()))
+	common.NatsJetstreamPublish(ctx, natsProverProveFailedSubject, p.payload())
+	msg.Term()
+}
+
+// updateStatus updates the proof status and optional description; the encrypted witness
+// is cleared once the proof is completed or failed
+func (p *Proof) updateStatus(db *gorm.DB, status string, description *string) error {
+	p.Status = common.StringOrNil(status)
+	p.Description = description
+	if status == proofStatusCompleted || status == proofStatusFailed {
+		p.Witness = nil
+	}
+
+	result := db.Save(&p)
+	errors := result.GetErrors()
+	if len(errors) > 0 {
+		for _, err := range errors {
+			common.Log.Warningf("failed to update status of proof %s; %s", p.ID, err.Error())
+		}
+		return errors[0]
+	}
+
+	return nil
+}
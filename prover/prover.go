@@ -18,6 +18,7 @@ package prover
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -25,25 +26,31 @@ import (
 	"math/big"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/jinzhu/gorm"
 	dbconf "github.com/kthomas/go-db-config"
-	natsutil "github.com/kthomas/go-natsutil"
 	uuid "github.com/kthomas/go.uuid"
 	"github.com/provideplatform/privacy/common"
+	deployer "github.com/provideplatform/privacy/deployer/providers"
 	"github.com/provideplatform/privacy/state"
 	storage "github.com/provideplatform/privacy/store"
 	storeprovider "github.com/provideplatform/privacy/store/providers"
+	"github.com/provideplatform/privacy/store/providers/mmt"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
 	zkp "github.com/provideplatform/privacy/zkp/providers"
+	"github.com/provideplatform/privacy/zkp/verifier"
 	provide "github.com/provideplatform/provide-go/api"
 	vault "github.com/provideplatform/provide-go/api/vault"
 	util "github.com/provideplatform/provide-go/common/util"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const proverProvingSchemeGroth16 = "groth16"
 const proverProvingSchemePlonk = "plonk"
 
+const proverStatusInit = "init"
 const proverStatusFailed = "failed"
 const proverStatusCompiling = "compiling"
 const proverStatusCompiled = "compiled"
@@ -84,6 +91,16 @@ type Prover struct {
 
 	Status *string `sql:"not null;default:'init'" json:"status"`
 
+	// optional runtime circuit definition, i.e., {"constraints": [{"name": "Amount", "operator": "<=", "value": "1000"}]};
+	// the circuit is registered with the prover provider under the prover identifier
+	ConstraintSpec *json.RawMessage `sql:"type:json" json:"constraint_spec,omitempty"`
+
+	// variables used at compile time (i.e., {"ProverMemberName_count": "3"}); applied when building witnesses
+	Variables *json.RawMessage `sql:"type:json" json:"variables,omitempty"`
+
+	// verify-only provers are imported from a verifying key (and optional SRS) and cannot generate proofs
+	VerifyOnly bool `sql:"not null;default:false" json:"verify_only"`
+
 	// SRS (structured reference string) is protocol-specific and may be nil depending on the proving scheme
 	StructuredReferenceStringID *uuid.UUID `gorm:"column:srs_id" json:"srs_id,omitempty"`
 
@@ -91,6 +108,21 @@ type Prover struct {
 	NoteStoreID *uuid.UUID `sql:"type:uuid" json:"note_store_id"`
 	noteStore   *storage.Store
 
+	// optional note store provider used when initializing note storage, i.e., mmt for
+	// notes provable using the in-circuit membership gadget; defaults to dmt
+	NoteStoreProvider *string `sql:"-" json:"note_store_provider,omitempty"`
+
+	// optional rollup prover; when set, notes are queued and periodically inserted into the note
+	// store in batches whose insertion is proven by the rollup prover
+	RollupProverID *uuid.UUID `sql:"type:uuid" json:"rollup_prover_id,omitempty"`
+
+	// size in bytes of the encoded proving key, recorded when the keys are persisted; used to
+	// estimate the memory required to generate a proof without resolving the proving key
+	ProvingKeySize *int64 `json:"proving_key_size,omitempty"`
+
+	// optional max duration of proof generation in seconds; defaults to PROVER_MAX_PROVING_DURATION
+	MaxProvingDuration *uint64 `json:"max_proving_duration,omitempty"`
+
 	// storage for hashed proofs (nullifiers)
 	NullifierStoreID *uuid.UUID `sql:"type:uuid" json:"nullifier_store_id"`
 	nullifierStore   *storage.Store
@@ -100,6 +132,13 @@ type Prover struct {
 	provingKey   []byte
 	verifyingKey []byte
 
+	// optional on-chain verification check run during setup, i.e., {"witness": {...}}
+	onChainCheck map[string]interface{}
+
+	// set when setup fails due to a transient error (i.e., vault or database unavailability)
+	// such that setup may be retried
+	setupRetryable bool
+
 	// artifacts
 	Artifacts map[string]interface{} `sql:"-" json:"artifacts,omitempty"`
 
@@ -112,6 +151,11 @@ type Prover struct {
 	verifierContractArtifact []byte
 	verifierContractSource   []byte
 
+	// optional verifier contract deployment; the deployer is resolved during setup
+	VerifierContractDeployer        *string `json:"verifier_contract_deployer,omitempty"`
+	VerifierContractAddress         *string `json:"verifier_contract_address,omitempty"`
+	VerifierContractTransactionHash *string `gorm:"column:verifier_contract_tx_hash" json:"verifier_contract_tx_hash,omitempty"`
+
 	// mutex
 	mutex sync.Mutex
 }
@@ -124,7 +168,24 @@ func (c *Prover) proverProviderFactory() zkp.ZKSnarkProverProvider {
 
 	switch *c.Provider {
 	case zkp.ZKSnarkProverProviderGnark:
-		return zkp.InitGnarkProverProvider(c.Curve, c.ProvingScheme)
+		provider := zkp.InitGnarkProverProvider(c.Curve, c.ProvingScheme)
+		provider.SetCacheScope(c.artifactCacheScope())
+		provider.SetArtifactIDs(secretIDString(c.ProvingKeyID), secretIDString(c.VerifyingKeyID), secretIDString(c.StructuredReferenceStringID))
+		provider.SetArtifactSource(c.fetchArtifact)
+		if c.ConstraintSpec != nil && c.Identifier != nil {
+			circuit, err := c.constraintSpecCircuit()
+			if err != nil {
+				common.Log.Warningf("failed to initialize prover provider; %s", err.Error())
+				return nil
+			}
+
+			err = provider.AddProver(*c.Identifier, circuit)
+			if err != nil {
+				common.Log.Warningf("failed to initialize prover provider; %s", err.Error())
+				return nil
+			}
+		}
+		return provider
 	case zkp.ZKSnarkProverProviderZoKrates:
 		return nil // not implemented
 	default:
@@ -134,8 +195,18 @@ func (c *Prover) proverProviderFactory() zkp.ZKSnarkProverProvider {
 	return nil
 }
 
+// constraintSpecCircuit builds the runtime circuit for the prover constraint spec
+func (c *Prover) constraintSpecCircuit() (*gnark.ConstraintSpecCircuit, error) {
+	spec, err := gnark.ParseConstraintSpec(*c.ConstraintSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return gnark.NewConstraintSpecCircuit(spec)
+}
+
 // Create a prover
-func (c *Prover) Create(variables interface{}) bool {
+func (c *Prover) Create(ctx context.Context, variables interface{}) bool {
 	if !c.validate() {
 		return false
 	}
@@ -143,7 +214,10 @@ func (c *Prover) Create(variables interface{}) bool {
 	db := dbconf.DatabaseConnection()
 	isImport := c.Artifacts != nil
 
-	if !c.importArtifacts(db) && !c.compile(db, variables) {
+	// provers which are not imported from artifacts are compiled asynchronously
+	if isImport && !c.importArtifacts(db) {
+		return false
+	} else if !isImport && !c.prepareCompile(variables) {
 		return false
 	}
 
@@ -199,15 +273,18 @@ func (c *Prover) Create(variables interface{}) bool {
 					}
 				}
 
-				if c.setupRequired() {
-					c.updateStatus(db, proverStatusPendingSetup, nil)
-
-					payload, _ := json.Marshal(map[string]interface{}{
-						"prover_id": c.ID.String(),
-					})
-					natsutil.NatsJetstreamPublish(natsCreatedProverSetupSubject, payload)
-				} else if isImport {
+				if isImport {
 					c.updateStatus(db, proverStatusProvisioned, nil)
+				} else {
+					err := c.requestCompile(ctx)
+					if err != nil {
+						common.Log.Warning(err.Error())
+						c.updateStatus(db, proverStatusFailed, common.StringOrNil(err.Error()))
+						c.Errors = append(c.Errors, &provide.Error{
+							Message: common.StringOrNil(err.Error()),
+						})
+						return false
+					}
 				}
 			}
 
@@ -259,6 +336,20 @@ func (c *Prover) NoteStoreRoot() (*string, error) {
 	return c.noteStore.Root()
 }
 
+// NoteMembershipProof returns the membership proof for the note at the given index; only
+// supported by note stores using the mimc merkle tree provider
+func (c *Prover) NoteMembershipProof(index uint64) (*mmt.MembershipProof, error) {
+	if c.noteStore == nil && c.NoteStoreID != nil {
+		c.noteStore = storage.Find(*c.NoteStoreID)
+	}
+
+	if c.noteStore == nil {
+		return nil, fmt.Errorf("failed to resolve note membership proof for index %d for prover %s", index, c.ID)
+	}
+
+	return c.noteStore.MembershipProof(index)
+}
+
 // NoteValueAt returns the decrypted note and key for nullified note from the underlying note storage provider
 func (c *Prover) NoteValueAt(index uint64) ([]byte, []byte, error) {
 	if c.noteStore == nil && c.NoteStoreID != nil {
@@ -327,25 +418,152 @@ func (c *Prover) NullifierValueAt(key []byte) ([]byte, error) {
 	return c.nullifierStore.ValueAt(key)
 }
 
-// Prove generates a proof for the given witness
-func (c *Prover) Prove(witness map[string]interface{}) (*string, error) {
-	err := c.enrich()
-	if err != nil {
-		common.Log.Warningf("enrich failed for proving prover %s; %s", c.ID, err.Error())
+// Prove generates a proof for the given witness; proof generation is abandoned when the given
+// context is done or the max proving duration of the prover is exceeded
+func (c *Prover) Prove(ctx context.Context, witness interface{}) (*string, error) {
+	if c.VerifyOnly {
+		return nil, fmt.Errorf("failed to generate proof for prover %s; prover is verify-only", c.ID)
 	}
 
+	ctx, span := common.StartSpan(ctx, "prover prove", c.spanAttributes()...)
+	defer span.End()
+
+	c.resolveStores()
+
 	provider := c.proverProviderFactory()
 	if provider == nil {
 		return nil, fmt.Errorf("failed to resolve prover provider")
 	}
 
-	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, false)
+	_, witnessSpan := common.StartSpan(ctx, "prover witness")
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), false)
+	common.EndSpan(witnessSpan, err)
 	if err != nil {
 		common.Log.Warningf("failed to read serialized witness for prover %s; %s", c.ID, err.Error())
 		return nil, err
 	}
 
-	proof, err := provider.Prove(c.Binary, c.provingKey, witval, c.srs)
+	return c.prove(ctx, provider, witval, witness)
+}
+
+// Compose generates a proof which recursively verifies the given completed proof of the inner
+// prover; the inner proof and its public witness are read from the proof record, and the outer
+// note references the inner proof by its id
+func (c *Prover) Compose(ctx context.Context, inner *Prover, innerProof *Proof, witness interface{}) (*string, error) {
+	if c.VerifyOnly {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; prover is verify-only", c.ID)
+	}
+
+	if c.Curve == nil || strings.ToLower(*c.Curve) != gnark.RecursiveProofCurve.String() {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; curve %s required", c.ID, gnark.RecursiveProofCurve.String())
+	}
+
+	if inner.Curve == nil || strings.ToLower(*inner.Curve) != gnark.RecursiveProofInnerCurve.String() || inner.ProvingScheme == nil || strings.ToLower(*inner.ProvingScheme) != proverProvingSchemeGroth16 {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; inner prover %s must use the %s proving scheme on curve %s", c.ID, inner.ID, proverProvingSchemeGroth16, gnark.RecursiveProofInnerCurve.String())
+	}
+
+	if innerProof.ProverID != inner.ID {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; proof %s does not belong to inner prover %s", c.ID, innerProof.ID, inner.ID)
+	}
+
+	if innerProof.Status == nil || *innerProof.Status != proofStatusCompleted || innerProof.Proof == nil || innerProof.PublicWitness == nil {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; inner proof %s is not completed", c.ID, innerProof.ID)
+	}
+
+	ctx, span := common.StartSpan(ctx, "prover compose", c.spanAttributes()...)
+	defer span.End()
+
+	c.resolveStores()
+
+	// the encoded verifying key of the inner prover is part of the outer witness
+	err := inner.fetchVerifyingKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; failed to resolve verifying key of inner prover %s; %s", c.ID, inner.ID, err.Error())
+	}
+
+	if inner.verifyingKey == nil || len(inner.verifyingKey) == 0 {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; inner prover %s has no verifying key", c.ID, inner.ID)
+	}
+
+	_innerProof, err := hex.DecodeString(*innerProof.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; failed to decode inner proof %s; %s", c.ID, innerProof.ID, err.Error())
+	}
+
+	publicWitness, err := hex.DecodeString(*innerProof.PublicWitness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; failed to decode public witness of inner proof %s; %s", c.ID, innerProof.ID, err.Error())
+	}
+
+	innerProvider := inner.proverProviderFactory()
+	if innerProvider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider for inner prover %s", inner.ID)
+	}
+
+	innerWitval, err := innerProvider.WitnessFactory(*inner.Identifier, *inner.Curve, *innerProof.PublicWitness, inner.compileVariables(), true)
+	if err != nil {
+		common.Log.Warningf("failed to read public witness of inner proof %s; %s", innerProof.ID, err.Error())
+		return nil, err
+	}
+
+	err = innerProvider.Verify(ctx, _innerProof, inner.verifyingKey, innerWitval, inner.srs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; inner proof not verified; %s", c.ID, err.Error())
+	}
+
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider")
+	}
+
+	recursiveProvider, recursiveProviderOk := provider.(zkp.RecursiveProverProvider)
+	if !recursiveProviderOk {
+		return nil, fmt.Errorf("failed to compose proof for prover %s; prover provider does not support recursion", c.ID)
+	}
+
+	witval, err := recursiveProvider.ComposeWitness(*c.Identifier, *c.Curve, witness, c.compileVariables(), _innerProof, inner.verifyingKey, publicWitness)
+	if err != nil {
+		common.Log.Warningf("failed to compose witness for prover %s; %s", c.ID, err.Error())
+		return nil, err
+	}
+
+	return c.prove(ctx, provider, witval, map[string]interface{}{
+		"inner_prover_id": inner.ID.String(),
+		"inner_proof_id":  innerProof.ID.String(),
+		"inner_proof":     *innerProof.Proof,
+		"witness":         witness,
+	})
+}
+
+// prove generates a proof for the given witness value and records a note containing the proof
+// and the given witness in the note store
+func (c *Prover) prove(ctx context.Context, provider zkp.ZKSnarkProverProvider, witval, witness interface{}) (*string, error) {
+	_proof, err := c.generateProof(ctx, provider, witval)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.updateState(ctx, *_proof, witness)
+	if err != nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("failed to update state for prover %s with identifier %s; %s", c.ID, *c.Identifier, err.Error())),
+		})
+		return nil, err
+	}
+
+	return _proof, nil
+}
+
+// generateProof generates the hex-encoded proof for the given witness value without updating the prover state
+func (c *Prover) generateProof(ctx context.Context, provider zkp.ZKSnarkProverProvider, witval interface{}) (*string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.maxProvingDuration())
+	defer cancel()
+
+	proofsInFlight.Inc()
+	startedAt := time.Now()
+	proof, err := provider.Prove(ctx, c.Binary, c.provingKey, witval, c.srs)
+	c.observeDuration(proveDuration, startedAt)
+	proofsInFlight.Dec()
 	if err != nil {
 		common.Log.Warningf("failed to generate proof for prover %s; %s", c.ID, err.Error())
 		return nil, err
@@ -362,52 +580,84 @@ func (c *Prover) Prove(witness map[string]interface{}) (*string, error) {
 
 	_proof := common.StringOrNil(hex.EncodeToString(buf.Bytes()))
 	common.Log.Debugf("generated proof for prover %s with identifier %s: %s", c.ID, *c.Identifier, *_proof)
+	return _proof, nil
+}
+
+// Solve runs the constraint solver for the given witness without generating a proof;
+// returns a *zkp.ConstraintError if the witness does not satisfy the circuit
+func (c *Prover) Solve(witness interface{}) error {
+	if c.Binary == nil || len(c.Binary) == 0 {
+		return fmt.Errorf("failed to solve witness for prover %s; no compiled artifacts", c.ID)
+	}
 
-	err = c.updateState(*_proof, witness)
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return fmt.Errorf("failed to resolve prover provider")
+	}
+
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), false)
 	if err != nil {
-		c.Errors = append(c.Errors, &provide.Error{
-			Message: common.StringOrNil(fmt.Sprintf("failed to update state for prover %s with identifier %s; %s", c.ID, *c.Identifier, err.Error())),
-		})
-		return nil, err
+		common.Log.Warningf("failed to read serialized witness for prover %s; %s", c.ID, err.Error())
+		return err
 	}
 
-	return _proof, nil
+	err = provider.Solve(c.Binary, witval)
+	if err != nil {
+		common.Log.Debugf("witness not solved for prover %s; %s", c.ID, err.Error())
+		return err
+	}
+
+	common.Log.Debugf("witness solved for prover %s", c.ID)
+	return nil
 }
 
-// Verify a proof to be verifiable for the given witness
-func (c *Prover) Verify(proof string, witness map[string]interface{}, store bool) (bool, error) {
-	err := c.enrich()
-	if err != nil {
-		common.Log.Warningf("enrich failed for verifying prover %s with identifier %s; %s", c.ID, *c.Identifier, err.Error())
+// WitnessSchema describes the witness expected by the prover, applying the variables used at compile time
+func (c *Prover) WitnessSchema() (map[string]interface{}, error) {
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider")
 	}
 
+	return provider.WitnessSchema(*c.Identifier, c.compileVariables())
+}
+
+// Verify a proof to be verifiable for the given witness; verification is abandoned when the given context is done
+func (c *Prover) Verify(ctx context.Context, proof string, witness interface{}, store bool) (bool, error) {
+	ctx, span := common.StartSpan(ctx, "prover verify", c.spanAttributes()...)
+	defer span.End()
+
+	c.resolveStores()
+
 	provider := c.proverProviderFactory()
 	if provider == nil {
 		return false, fmt.Errorf("failed to resolve prover provider")
 	}
 
-	var _proof []byte
-
-	_proof, err = hex.DecodeString(proof)
+	_proof, err := hex.DecodeString(proof)
 	if err != nil {
 		common.Log.Debugf("failed to decode proof as hex for verification of prover %s; %s", c.ID, err.Error())
 		_proof = []byte(proof)
 	}
 
-	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, true)
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), true)
 	if err != nil {
 		common.Log.Warningf("failed to read serialized witness for prover %s; %s", c.ID, err.Error())
 		return false, err
 	}
 
-	err = provider.Verify(_proof, c.verifyingKey, witval, c.srs)
+	startedAt := time.Now()
+	err = provider.Verify(ctx, _proof, c.verifyingKey, witval, c.srs)
+	c.observeDuration(verifyDuration, startedAt)
 	if err != nil {
+		if ctx.Err() == nil {
+			verificationFailures.WithLabelValues(c.metricLabels()...).Inc()
+		}
 		common.Log.Debugf("failed to verify witness for prover %s; proof: %s; %s", c.ID, proof, err.Error())
 		return false, err
 	}
 
 	if store {
-		err = c.updateState(string(_proof), witness)
+		err = c.updateState(ctx, string(_proof), witness)
 		if err != nil {
 			c.Errors = append(c.Errors, &provide.Error{
 				Message: common.StringOrNil(fmt.Sprintf("failed to update state for prover %s with identifier %s; %s", c.ID, *c.Identifier, err.Error())),
@@ -420,6 +670,77 @@ func (c *Prover) Verify(proof string, witness map[string]interface{}, store bool
 	return true, nil
 }
 
+// BatchVerify verifies each of the given proofs against the corresponding witness; the prover
+// verifying key is resolved once, and the returned slice contains the verification error of
+// each proof, or nil if the proof was verified
+func (c *Prover) BatchVerify(proofs []string, witnesses []interface{}) ([]error, error) {
+	if len(proofs) != len(witnesses) {
+		return nil, fmt.Errorf("failed to batch verify %d proof(s) for prover %s; %d witness(es) provided", len(proofs), c.ID, len(witnesses))
+	}
+
+	c.resolveStores()
+
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider")
+	}
+
+	batchProvider, batchProviderOk := provider.(zkp.BatchVerifierProvider)
+	if !batchProviderOk {
+		return nil, fmt.Errorf("failed to batch verify proofs for prover %s; batch verification not supported by prover provider", c.ID)
+	}
+
+	results := make([]error, len(proofs))
+	indices := make([]int, 0, len(proofs))
+	_proofs := make([][]byte, 0, len(proofs))
+	publicWitnesses := make([][]byte, 0, len(proofs))
+
+	for i := range proofs {
+		_proof, err := hex.DecodeString(proofs[i])
+		if err != nil {
+			_proof = []byte(proofs[i])
+		}
+
+		// the public witness of each item is assigned to a new circuit instance by the provider
+		witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witnesses[i], c.compileVariables(), true)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+
+		publicWitness, err := batchProvider.PublicWitness(witval)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+
+		indices = append(indices, i)
+		_proofs = append(_proofs, _proof)
+		publicWitnesses = append(publicWitnesses, publicWitness)
+	}
+
+	startedAt := time.Now()
+	batchResults, err := batchProvider.BatchVerify(_proofs, c.verifyingKey, publicWitnesses, c.srs)
+	c.observeDuration(verifyDuration, startedAt)
+	if err != nil {
+		common.Log.Warningf("failed to batch verify %d proof(s) for prover %s; %s", len(_proofs), c.ID, err.Error())
+		return nil, err
+	}
+
+	for i, index := range indices {
+		results[index] = batchResults[i]
+	}
+
+	for _, err := range results {
+		if err != nil {
+			verificationFailures.WithLabelValues(c.metricLabels()...).Inc()
+		}
+	}
+
+	common.Log.Debugf("batch verified %d proof(s) for prover %s", len(proofs), c.ID)
+	return results, nil
+}
+
 // compile attempts to compile the prover
 func (c *Prover) compile(db *gorm.DB, variables interface{}) bool {
 	c.updateStatus(db, proverStatusCompiling, nil)
@@ -439,7 +760,9 @@ func (c *Prover) compile(db *gorm.DB, variables interface{}) bool {
 		prover := provider.ProverFactory(*c.Identifier)
 
 		if prover != nil {
+			startedAt := time.Now()
 			artifacts, err = provider.Compile(prover, variables)
+			c.observeDuration(compileDuration, startedAt)
 			if err != nil {
 				c.Errors = append(c.Errors, &provide.Error{
 					Message: common.StringOrNil(fmt.Sprintf("failed to compile prover with identifier %s; %s", *c.Identifier, err.Error())),
@@ -454,6 +777,17 @@ func (c *Prover) compile(db *gorm.DB, variables interface{}) bool {
 		}
 	}
 
+	if variables != nil {
+		rawVariables, err := json.Marshal(variables)
+		if err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("failed to marshal variables for prover with identifier %s; %s", *c.Identifier, err.Error())),
+			})
+			return false
+		}
+		c.Variables = (*json.RawMessage)(&rawVariables)
+	}
+
 	buf := new(bytes.Buffer)
 	_, err = artifacts.(io.WriterTo).WriteTo(buf)
 	if err != nil {
@@ -468,109 +802,148 @@ func (c *Prover) compile(db *gorm.DB, variables interface{}) bool {
 	return len(c.Errors) == 0
 }
 
-// canExportVerifier returns true if the prover instance supports exporting a verifier smart contract
-func (c *Prover) canExportVerifier() bool {
-	return c.VerifierContract == nil && strings.ToLower(*c.ProvingScheme) == proverProvingSchemeGroth16 && strings.ToLower(*c.Curve) == ecc.BN254.String()
-}
+// prepareCompile ensures the prover identifier resolves to a circuit which can be compiled
+// asynchronously and retains the given compile-time variables
+func (c *Prover) prepareCompile(variables interface{}) bool {
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil("failed to resolve prover provider"),
+		})
+		return false
+	}
 
-// enrich the prover
-func (c *Prover) enrich() error {
-	if (c.provingKey == nil || len(c.provingKey) == 0) && c.ProvingKeyID != nil {
-		secret, err := vault.FetchSecret(
-			util.DefaultVaultAccessJWT,
-			c.VaultID.String(),
-			c.ProvingKeyID.String(),
-			map[string]interface{}{},
-		)
-		if err != nil {
-			return err
-		}
-		c.provingKey, err = hex.DecodeString(*secret.Value)
-		if err != nil {
-			common.Log.Warningf("failed to decode proving key secret from hex; %s", err.Error())
-			return err
-		}
+	if provider.ProverFactory(*c.Identifier) == nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("failed to resolve prover %s for provider: %s", *c.Identifier, *c.Provider)),
+		})
+		return false
 	}
 
-	if (c.provingKey == nil || len(c.provingKey) == 0) && c.ProvingKeyID != nil {
-		secret, err := vault.FetchSecret(
-			util.DefaultVaultAccessJWT,
-			c.VaultID.String(),
-			c.ProvingKeyID.String(),
-			map[string]interface{}{},
-		)
+	if variables != nil {
+		rawVariables, err := json.Marshal(variables)
 		if err != nil {
-			return err
-		}
-		c.provingKey, err = hex.DecodeString(*secret.Value)
-		if err != nil {
-			common.Log.Warningf("failed to decode proving key secret from hex; %s", err.Error())
-			return err
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("failed to marshal variables for prover with identifier %s; %s", *c.Identifier, err.Error())),
+			})
+			return false
 		}
+		c.Variables = (*json.RawMessage)(&rawVariables)
 	}
 
-	if (c.verifyingKey == nil || len(c.verifyingKey) == 0) && c.VerifyingKeyID != nil {
-		secret, err := vault.FetchSecret(
-			util.DefaultVaultAccessJWT,
-			c.VaultID.String(),
-			c.VerifyingKeyID.String(),
-			map[string]interface{}{},
-		)
-		if err != nil {
-			return err
-		}
-		c.verifyingKey, err = hex.DecodeString(*secret.Value)
-		if err != nil {
-			common.Log.Warningf("failed to decode verifying key secret from hex; %s", err.Error())
-			return err
-		}
+	c.Status = common.StringOrNil(proverStatusInit)
+	return true
+}
+
+// requestCompile publishes the prover for asynchronous compilation and setup
+func (c *Prover) requestCompile(ctx context.Context) error {
+	params := map[string]interface{}{
+		"prover_id": c.ID.String(),
+	}
+	if c.onChainCheck != nil {
+		params["on_chain_check"] = c.onChainCheck
 	}
 
-	if (c.srs == nil || len(c.srs) == 0) && c.StructuredReferenceStringID != nil {
-		secret, err := vault.FetchSecret(
-			util.DefaultVaultAccessJWT,
-			c.VaultID.String(),
-			c.StructuredReferenceStringID.String(),
-			map[string]interface{}{},
-		)
-		if err != nil {
-			return err
-		}
-		c.srs, err = hex.DecodeString(*secret.Value)
-		if err != nil {
-			common.Log.Warningf("failed to decode SRS secret from hex; %s", err.Error())
-			return err
-		}
+	payload, _ := json.Marshal(params)
+	_, err := common.NatsJetstreamPublish(ctx, natsCreatedProverCompileSubject, payload)
+	if err != nil {
+		return fmt.Errorf("failed to publish prover %s for compilation; %s", c.ID, err.Error())
+	}
+
+	return nil
+}
+
+// requestSetup publishes the compiled prover for asynchronous setup
+func (c *Prover) requestSetup(ctx context.Context, db *gorm.DB) error {
+	c.updateStatus(db, proverStatusPendingSetup, nil)
+
+	params := map[string]interface{}{
+		"prover_id": c.ID.String(),
+	}
+	if c.onChainCheck != nil {
+		params["on_chain_check"] = c.onChainCheck
+	}
+
+	payload, _ := json.Marshal(params)
+	_, err := common.NatsJetstreamPublish(ctx, natsCreatedProverSetupSubject, payload)
+	if err != nil {
+		return fmt.Errorf("failed to publish prover %s for setup; %s", c.ID, err.Error())
+	}
+
+	return nil
+}
+
+// compileVariables returns the variables used when the prover was compiled, if any
+func (c *Prover) compileVariables() map[string]interface{} {
+	if c.Variables == nil {
+		return nil
+	}
+
+	var variables map[string]interface{}
+	err := json.Unmarshal(*c.Variables, &variables)
+	if err != nil {
+		common.Log.Warningf("failed to unmarshal variables for prover %s; %s", c.ID, err.Error())
+		return nil
+	}
+
+	return variables
+}
+
+// maxProvingDuration returns the max duration of proof generation for the prover
+func (c *Prover) maxProvingDuration() time.Duration {
+	if c.MaxProvingDuration != nil && *c.MaxProvingDuration > 0 {
+		return time.Duration(*c.MaxProvingDuration) * time.Second
+	}
+
+	return common.MaxProvingDuration
+}
+
+// canExportVerifier returns true if the prover instance supports exporting a verifier smart contract
+func (c *Prover) canExportVerifier() bool {
+	return c.VerifierContract == nil && strings.ToLower(*c.ProvingScheme) == proverProvingSchemeGroth16 && strings.ToLower(*c.Curve) == ecc.BN254.String()
+}
+
+// enrich the prover, resolving its proving key, verifying key and srs from vault, i.e. to render,
+// persist or export them; the artifacts used to prove and verify are otherwise resolved by the
+// prover provider only when the decoded artifacts are not cached
+func (c *Prover) enrich(ctx context.Context) error {
+	ctx, span := common.StartSpan(ctx, "prover enrich", c.spanAttributes()...)
+	defer span.End()
+
+	err := c.fetchArtifacts(ctx)
+	if err != nil {
+		return err
 	}
 
 	if c.Artifacts == nil {
 		c.Artifacts = map[string]interface{}{
-			"binary":        hex.EncodeToString(c.Binary),
-			"proving_key":   hex.EncodeToString(c.provingKey),
 			"verifying_key": hex.EncodeToString(c.verifyingKey),
 		}
 
+		if !c.VerifyOnly {
+			c.Artifacts["binary"] = hex.EncodeToString(c.Binary)
+			c.Artifacts["proving_key"] = hex.EncodeToString(c.provingKey)
+		}
+
 		if c.srs != nil && len(c.srs) > 0 {
 			c.Artifacts["srs"] = hex.EncodeToString(c.srs)
 		}
 	}
 
-	if c.noteStore == nil && c.NoteStoreID != nil {
-		c.noteStore = storage.Find(*c.NoteStoreID)
-	}
-
-	if c.nullifierStore == nil && c.NullifierStoreID != nil {
-		c.nullifierStore = storage.Find(*c.NullifierStoreID)
-	}
+	c.resolveStores()
 
 	if c.canExportVerifier() {
-		err := c.exportVerifier()
+		err = c.exportVerifier()
 		if err != nil {
 			common.Log.Debugf("failed to export verifier contract for prover %s; %s", c.ID, err.Error())
 		} else if c.verifierContractSource != nil && len(c.verifierContractSource) > 0 {
 			c.VerifierContract = map[string]interface{}{
 				"source": string(c.verifierContractSource),
 			}
+
+			if c.VerifierContractAddress != nil {
+				c.VerifierContract["address"] = *c.VerifierContractAddress
+			}
 		}
 	}
 
@@ -580,14 +953,133 @@ func (c *Prover) enrich() error {
 	return nil
 }
 
+// fetchArtifacts resolves the proving key, verifying key and srs of the prover from vault, if not resolved
+func (c *Prover) fetchArtifacts(ctx context.Context) error {
+	var err error
+
+	if (c.provingKey == nil || len(c.provingKey) == 0) && c.ProvingKeyID != nil {
+		c.provingKey, err = c.fetchSecret(ctx, *c.ProvingKeyID)
+		if err != nil {
+			common.Log.Warningf("failed to resolve proving key secret; %s", err.Error())
+			return err
+		}
+	}
+
+	err = c.fetchVerifyingKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if (c.srs == nil || len(c.srs) == 0) && c.StructuredReferenceStringID != nil {
+		c.srs, err = c.fetchSecret(ctx, *c.StructuredReferenceStringID)
+		if err != nil {
+			common.Log.Warningf("failed to resolve SRS secret; %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchVerifyingKey resolves the verifying key of the prover from vault, if not resolved
+func (c *Prover) fetchVerifyingKey(ctx context.Context) error {
+	var err error
+
+	if (c.verifyingKey == nil || len(c.verifyingKey) == 0) && c.VerifyingKeyID != nil {
+		c.verifyingKey, err = c.fetchSecret(ctx, *c.VerifyingKeyID)
+		if err != nil {
+			common.Log.Warningf("failed to resolve verifying key secret; %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveStores resolves the note and nullifier stores of the prover, if not resolved
+func (c *Prover) resolveStores() {
+	if c.noteStore == nil && c.NoteStoreID != nil {
+		c.noteStore = storage.Find(*c.NoteStoreID)
+	}
+
+	if c.nullifierStore == nil && c.NullifierStoreID != nil {
+		c.nullifierStore = storage.Find(*c.NullifierStoreID)
+	}
+}
+
 // // exportState exports the state of the prover at the given epoch
 // func (c *Prover) exportState(epoch uint64) (*state.State, error) {
 // 	noteState, _ := c.noteStore.StateAt(epoch)
 // 	nullifiedState, _ := c.nullifierStore.StateAt(epoch) // spent
 
-// 	common.Log.Debugf("resolved note and nullified state; %s; %s", noteState, nullifiedState)
-// 	return nullifiedState, nil
-// }
+// 	common.Log.Debugf("resolved note and nullified state; %s; %s", noteState, nullifiedState)
+// 	return nullifiedState, nil
+// }
+
+// artifactCacheScope returns the prefix of the artifact cache keys of the prover
+func (c *Prover) artifactCacheScope() string {
+	return fmt.Sprintf("prover/%s/", c.ID.String())
+}
+
+// evictArtifacts removes the decoded artifacts of the prover from the artifact cache, i.e.
+// prior to setup or rotation of the proving and verifying keys
+func (c *Prover) evictArtifacts() {
+	evicted := common.ArtifactCache.RemovePrefix(c.artifactCacheScope())
+	if evicted > 0 {
+		common.Log.Debugf("evicted %d cached artifact(s) for prover %s", evicted, c.ID)
+	}
+}
+
+// secretIDString returns the given vault secret id as a string, or an empty string if nil
+func secretIDString(secretID *uuid.UUID) string {
+	if secretID == nil {
+		return ""
+	}
+	return secretID.String()
+}
+
+// secretUnavailableError is returned when a secret of the prover could not be fetched from vault
+type secretUnavailableError struct {
+	secretID uuid.UUID
+	err      error
+}
+
+func (e *secretUnavailableError) Error() string {
+	return fmt.Sprintf("failed to fetch secret %s from vault; %s", e.secretID, e.err.Error())
+}
+
+// fetchArtifact resolves the encoded artifact with the given vault secret id; used by the prover
+// provider to resolve the artifacts of the prover on an artifact cache miss
+func (c *Prover) fetchArtifact(id string) ([]byte, error) {
+	secretID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve artifact; invalid secret id: %s", id)
+	}
+
+	return c.fetchSecret(context.Background(), secretID)
+}
+
+// fetchSecret resolves the hex-encoded secret with the given id from the prover vault
+func (c *Prover) fetchSecret(ctx context.Context, secretID uuid.UUID) ([]byte, error) {
+	_, span := common.StartSpan(ctx, "vault fetch secret", attribute.String("vault.secret_id", secretID.String()))
+	secret, err := vault.FetchSecret(
+		util.DefaultVaultAccessJWT,
+		c.VaultID.String(),
+		secretID.String(),
+		map[string]interface{}{},
+	)
+	common.EndSpan(span, err)
+	if err != nil {
+		return nil, &secretUnavailableError{secretID: secretID, err: err}
+	}
+
+	val, err := hex.DecodeString(*secret.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret %s from hex; %s", secretID, err.Error())
+	}
+
+	return val, nil
+}
 
 func (c *Prover) exportVerifier() error {
 	provider := c.proverProviderFactory()
@@ -702,6 +1194,10 @@ func (c *Prover) initNoteStorage() error {
 		Curve:    common.StringOrNil(*c.Curve),
 	}
 
+	if c.NoteStoreProvider != nil {
+		store.Provider = common.StringOrNil(*c.NoteStoreProvider)
+	}
+
 	if store.Create() {
 		common.Log.Debugf("initialized notes storage for prover with identifier %s", c.ID)
 		c.NoteStoreID = &store.ID
@@ -742,8 +1238,12 @@ func (c *Prover) initNullifierStorage() error {
 }
 
 // generateEncryptionKey attempts to generate an AES-256-GCM symmetric key for encrypting
-// notes and persist the key id on the prover instance
+// notes and persist the key id on the prover instance; an existing key is reused
 func (c *Prover) generateEncryptionKey() bool {
+	if c.EncryptionKeyID != nil {
+		return true
+	}
+
 	key, err := vault.CreateKey(
 		util.DefaultVaultAccessJWT,
 		c.VaultID.String(),
@@ -767,8 +1267,17 @@ func (c *Prover) generateEncryptionKey() bool {
 }
 
 // persistKeys attempts to persist the proving and verifying keys as secrets
-// in the configured vault instance
+// in the configured vault instance; only the verifying key is persisted for
+// verify-only provers, and keys which have already been persisted are reused
 func (c *Prover) persistKeys() bool {
+	if c.VerifyOnly {
+		return c.persistVerifyingKey()
+	}
+
+	if c.ProvingKeyID != nil {
+		return c.persistVerifyingKey()
+	}
+
 	secret, err := vault.CreateSecret(
 		util.DefaultVaultAccessJWT,
 		c.VaultID.String(),
@@ -787,7 +1296,19 @@ func (c *Prover) persistKeys() bool {
 	}
 	c.ProvingKeyID = &secret.ID
 
-	secret, err = vault.CreateSecret(
+	provingKeySize := int64(len(c.provingKey))
+	c.ProvingKeySize = &provingKeySize
+
+	return c.persistVerifyingKey() && c.ProvingKeyID != nil
+}
+
+// persistVerifyingKey attempts to persist the verifying key as a secret in the configured vault instance
+func (c *Prover) persistVerifyingKey() bool {
+	if c.VerifyingKeyID != nil {
+		return true
+	}
+
+	secret, err := vault.CreateSecret(
 		util.DefaultVaultAccessJWT,
 		c.VaultID.String(),
 		map[string]interface{}{
@@ -805,11 +1326,15 @@ func (c *Prover) persistKeys() bool {
 	}
 	c.VerifyingKeyID = &secret.ID
 
-	return c.ProvingKeyID != nil && c.VerifyingKeyID != nil
+	return c.VerifyingKeyID != nil
 }
 
 // persistSRS attempts to persist the prover SRS as a secret in the configured vault instance
 func (c *Prover) persistSRS() bool {
+	if c.StructuredReferenceStringID != nil {
+		return true
+	}
+
 	secret, err := vault.CreateSecret(
 		util.DefaultVaultAccessJWT,
 		c.VaultID.String(),
@@ -830,43 +1355,28 @@ func (c *Prover) persistSRS() bool {
 	return c.StructuredReferenceStringID != nil
 }
 
-func (c *Prover) setupRequired() bool {
-	return c.ProvingScheme != nil && (*c.ProvingScheme == proverProvingSchemeGroth16 || *c.ProvingScheme == proverProvingSchemePlonk) && c.Status != nil && (*c.Status == proverStatusCompiled || *c.Status == proverStatusPendingSetup)
+// keysPersisted returns true if the keys of the prover have been persisted, i.e. by a previous
+// setup attempt
+func (c *Prover) keysPersisted() bool {
+	return c.VerifyingKeyID != nil && (c.VerifyOnly || c.ProvingKeyID != nil)
 }
 
-// setup attempts to setup the prover
-func (c *Prover) setup(db *gorm.DB) bool {
-	if !c.setupRequired() {
-		common.Log.Warningf("attempted to setup prover for which setup is not required")
-		return false
-	}
-
-	c.updateStatus(db, proverStatusRunningSetup, nil)
-
-	if c.Binary == nil || len(c.Binary) == 0 {
-		c.Errors = append(c.Errors, &provide.Error{
-			Message: common.StringOrNil(fmt.Sprintf("failed to setup prover with identifier %s; no compiled artifacts", *c.Identifier)),
-		})
-		common.Log.Warningf("failed to setup prover with identifier %s; no compiled artifacts", *c.Identifier)
-		return false
-	}
-
-	provider := c.proverProviderFactory()
-	if provider == nil {
-		c.Errors = append(c.Errors, &provide.Error{
-			Message: common.StringOrNil("failed to resolve prover provider"),
-		})
-		return false
+// generateKeys attempts to generate the proving and verifying keys of the prover; a proving key
+// persisted by a previous setup attempt which failed to persist the verifying key is discarded,
+// as it does not correspond to the generated keys
+func (c *Prover) generateKeys(ctx context.Context, provider zkp.ZKSnarkProverProvider) bool {
+	if c.ProvingKeyID != nil {
+		err := vault.DeleteSecret(util.DefaultVaultAccessJWT, c.VaultID.String(), c.ProvingKeyID.String())
+		if err != nil {
+			common.Log.Warningf("failed to delete stale proving key secret %s for prover %s; %s", c.ProvingKeyID, c.ID, err.Error())
+		}
+		c.ProvingKeyID = nil
+		c.ProvingKeySize = nil
 	}
 
-	if c.srsRequired() && (c.srs == nil || len(c.srs) == 0) {
-		c.Errors = append(c.Errors, &provide.Error{
-			Message: common.StringOrNil(fmt.Sprintf("failed to acquire srs before setup for prover with identifier %s", *c.Identifier)),
-		})
-		common.Log.Warningf("failed to acquire srs before Setup for prover with identifier %s", *c.Identifier)
-		return false
-	}
-	pk, vk, err := provider.Setup(c.Binary, c.srs)
+	startedAt := time.Now()
+	pk, vk, err := provider.Setup(ctx, c.Binary, c.srs)
+	c.observeDuration(setupDuration, startedAt)
 
 	if err != nil {
 		c.Errors = append(c.Errors, &provide.Error{
@@ -904,6 +1414,55 @@ func (c *Prover) setup(db *gorm.DB) bool {
 	}
 	c.verifyingKey = vkBuf.Bytes()
 
+	return true
+}
+
+func (c *Prover) setupRequired() bool {
+	return c.ProvingScheme != nil && (*c.ProvingScheme == proverProvingSchemeGroth16 || *c.ProvingScheme == proverProvingSchemePlonk) && c.Status != nil && (*c.Status == proverStatusCompiled || *c.Status == proverStatusPendingSetup || *c.Status == proverStatusRunningSetup)
+}
+
+// setup attempts to setup the prover
+func (c *Prover) setup(ctx context.Context, db *gorm.DB) bool {
+	if !c.setupRequired() {
+		common.Log.Warningf("attempted to setup prover for which setup is not required")
+		return false
+	}
+
+	c.updateStatus(db, proverStatusRunningSetup, nil)
+	c.evictArtifacts()
+
+	if c.Binary == nil || len(c.Binary) == 0 {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("failed to setup prover with identifier %s; no compiled artifacts", *c.Identifier)),
+		})
+		common.Log.Warningf("failed to setup prover with identifier %s; no compiled artifacts", *c.Identifier)
+		return false
+	}
+
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil("failed to resolve prover provider"),
+		})
+		return false
+	}
+
+	if c.srsRequired() && (c.srs == nil || len(c.srs) == 0) {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("failed to acquire srs before setup for prover with identifier %s", *c.Identifier)),
+		})
+		common.Log.Warningf("failed to acquire srs before Setup for prover with identifier %s", *c.Identifier)
+		return false
+	}
+
+	// keys persisted by a previous setup attempt are reused, such that retried setup
+	// does not persist additional keys for the prover
+	if c.keysPersisted() {
+		common.Log.Debugf("reusing proving and verifying keys persisted for prover %s", c.ID)
+	} else if !c.generateKeys(ctx, provider) {
+		return false
+	}
+
 	if len(c.Errors) != 0 {
 		c.Errors = append(c.Errors, &provide.Error{
 			Message: common.StringOrNil(fmt.Sprintf("errors found while setting up prover with identifier %s", *c.Identifier)),
@@ -917,6 +1476,7 @@ func (c *Prover) setup(db *gorm.DB) bool {
 			Message: common.StringOrNil(fmt.Sprintf("failed to persist encryption key for prover with identifier %s", *c.Identifier)),
 		})
 		common.Log.Warningf("failed to persist encryption key for prover with identifier %s", *c.Identifier)
+		c.setupRetryable = true
 		return false
 	}
 
@@ -925,34 +1485,160 @@ func (c *Prover) setup(db *gorm.DB) bool {
 			Message: common.StringOrNil(fmt.Sprintf("failed to persist proving and verifying keys for prover with identifier %s", *c.Identifier)),
 		})
 		common.Log.Warningf("failed to persist proving and verifying keys for prover with identifier %s", *c.Identifier)
+		c.setupRetryable = true
 		return false
 	}
 
-	err = c.enrich()
+	err := c.enrich(ctx)
 	if err != nil {
 		c.Errors = append(c.Errors, &provide.Error{
 			Message: common.StringOrNil(fmt.Sprintf("failed to enrich prover with identifier %s; %s", *c.Identifier, err.Error())),
 		})
 		common.Log.Warningf("failed to enrich prover with identifier %s; %s", *c.Identifier, err.Error())
+		c.setupRetryable = true
 		return false
 	}
 
+	if c.onChainCheck != nil {
+		err = c.verifyOnChain()
+		if err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("on-chain verification check failed for prover with identifier %s; %s", *c.Identifier, err.Error())),
+			})
+			common.Log.Warningf("on-chain verification check failed for prover with identifier %s; %s", *c.Identifier, err.Error())
+			return false
+		}
+	}
+
+	// a verifier contract deployed by a previous setup attempt is not deployed again
+	if c.VerifierContractDeployer != nil && c.VerifierContractAddress == nil {
+		err = c.updateStatus(db, proverStatusDeployingArtifacts, nil)
+		if err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("failed to update status of prover with identifier %s; %s", *c.Identifier, err.Error())),
+			})
+			common.Log.Warningf("failed to update status of prover with identifier %s; %s", *c.Identifier, err.Error())
+			c.setupRetryable = true
+			return false
+		}
+
+		err = c.deployVerifier(db)
+		if err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("failed to deploy verifier contract for prover with identifier %s; %s", *c.Identifier, err.Error())),
+			})
+			common.Log.Warningf("failed to deploy verifier contract for prover with identifier %s; %s", *c.Identifier, err.Error())
+			c.setupRetryable = true
+			return false
+		}
+	}
+
 	err = c.updateStatus(db, proverStatusProvisioned, nil)
 	if err != nil {
 		c.Errors = append(c.Errors, &provide.Error{
 			Message: common.StringOrNil(fmt.Sprintf("failed to update status of prover with identifier %s; %s", *c.Identifier, err.Error())),
 		})
 		common.Log.Warningf("failed to update status of prover with identifier %s; %s", *c.Identifier, err.Error())
+		c.setupRetryable = true
 		return false
 	}
 
 	return true
 }
 
+// verifyOnChain generates a proof for the on-chain check witness and verifies it using
+// the exported verifier contract deployed to an in-process simulated chain
+func (c *Prover) verifyOnChain() error {
+	witness, witnessOk := c.onChainCheck["witness"].(map[string]interface{})
+	if !witnessOk {
+		return fmt.Errorf("witness required for on-chain verification check")
+	}
+
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return fmt.Errorf("failed to resolve prover provider")
+	}
+
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), false)
+	if err != nil {
+		return err
+	}
+
+	proof, err := provider.Prove(context.Background(), c.Binary, c.provingKey, witval, c.srs)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	_, err = proof.(io.WriterTo).WriteTo(buf)
+	if err != nil {
+		return err
+	}
+
+	err = provider.VerifyOnChain(buf.Bytes(), c.verifyingKey, witval)
+	if err != nil {
+		return err
+	}
+
+	common.Log.Debugf("on-chain verification check succeeded for prover %s", c.ID)
+	return nil
+}
+
+// deployVerifier deploys the exported verifier contract using the configured contract
+// deployer and persists the resulting contract address and transaction hash
+func (c *Prover) deployVerifier(db *gorm.DB) error {
+	contractDeployer, err := deployer.ContractDeployerFactory(*c.VerifierContractDeployer)
+	if err != nil {
+		return err
+	}
+
+	if c.verifierContractSource == nil || len(c.verifierContractSource) == 0 {
+		err = c.exportVerifier()
+		if err != nil {
+			return err
+		}
+	}
+
+	deployment, err := contractDeployer.Deploy(verifier.VerifierContractName, c.verifierContractSource)
+	if err != nil {
+		return err
+	}
+
+	c.VerifierContractAddress = common.StringOrNil(deployment.Address)
+	c.VerifierContractTransactionHash = common.StringOrNil(deployment.TransactionHash)
+
+	result := db.Model(&c).Updates(map[string]interface{}{
+		"verifier_contract_address": c.VerifierContractAddress,
+		"verifier_contract_tx_hash": c.VerifierContractTransactionHash,
+	})
+	if len(result.GetErrors()) > 0 {
+		return result.GetErrors()[0]
+	}
+
+	if c.VerifierContract != nil {
+		c.VerifierContract["address"] = *c.VerifierContractAddress
+	}
+
+	common.Log.Debugf("deployed verifier contract for prover %s at address %s; tx hash: %s", c.ID, *c.VerifierContractAddress, *c.VerifierContractTransactionHash)
+	return nil
+}
+
 func (c *Prover) srsRequired() bool {
 	return c.ProvingScheme != nil && *c.ProvingScheme == proverProvingSchemePlonk
 }
 
+// errorsDescription returns the messages of the prover errors, if any, as a single description
+func (c *Prover) errorsDescription() *string {
+	messages := make([]string, 0)
+	for _, err := range c.Errors {
+		if err != nil && err.Message != nil {
+			messages = append(messages, *err.Message)
+		}
+	}
+
+	return common.StringOrNil(strings.Join(messages, "; "))
+}
+
 // updateStatus updates the prover status and optional description
 func (c *Prover) updateStatus(db *gorm.DB, status string, description *string) error {
 	// FIXME-- use distributed lock here
@@ -969,12 +1655,23 @@ func (c *Prover) updateStatus(db *gorm.DB, status string, description *string) e
 			}
 			return errors[0]
 		}
+
+		_, err := c.dispatchNotification(context.Background(), natsProverNotificationStatus, map[string]interface{}{
+			"status":      status,
+			"description": description,
+		})
+		if err != nil {
+			common.Log.Debugf("failed to dispatch status notification for prover %s; %s", c.ID, err.Error())
+		}
 	}
 	return nil
 }
 
 // TODO-- add object, witness
-func (c *Prover) updateState(proof string, witness map[string]interface{}) error {
+func (c *Prover) updateState(ctx context.Context, proof string, witness interface{}) error {
+	ctx, span := common.StartSpan(ctx, "prover update state", c.spanAttributes()...)
+	defer span.End()
+
 	var note []byte
 
 	// FIXME -- adopt proper Note structure
@@ -990,12 +1687,14 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 	nullifiedIndex := -1
 
 	if c.noteStore != nil {
+		_, encryptSpan := common.StartSpan(ctx, "vault encrypt")
 		resp, err := vault.Encrypt(
 			util.DefaultVaultAccessJWT,
 			c.VaultID.String(),
 			c.EncryptionKeyID.String(),
 			string(note),
 		)
+		common.EndSpan(encryptSpan, err)
 		if err != nil {
 			common.Log.Warningf("failed to update state; failed to encrypt note for prover %s; %s", c.ID, err.Error())
 			return err
@@ -1007,6 +1706,11 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 			return err
 		}
 
+		if c.RollupProverID != nil {
+			// the note and its nullifier are inserted by the next rollup
+			return c.queueNote(dbconf.DatabaseConnection(), data)
+		}
+
 		nullifiedIndex, err = c.noteStore.Size()
 		if err != nil {
 			common.Log.Warningf("failed to get size of note store for prover %s; %s", c.ID, err.Error())
@@ -1029,19 +1733,22 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 			}
 
 			if nullifierExists {
+				doubleSpendRejections.WithLabelValues(c.metricLabels()...).Inc()
 				err := fmt.Errorf("attempt to double-spend %d-byte note for prover %s", len(note), c.ID)
 				common.Log.Warning(err.Error())
 				return err
 			}
 		}
 
+		_, insertSpan := common.StartSpan(ctx, "store insert note")
 		_, err = c.noteStore.Insert(string(data))
+		common.EndSpan(insertSpan, err)
 		if err != nil {
 			common.Log.Warningf("failed to update state; note not inserted for prover %s; %s", c.ID, err.Error())
 			return err
 		}
 
-		_, err = c.dispatchNotification(natsProverNotificationNoteDeposit)
+		_, err = c.dispatchNotification(ctx, natsProverNotificationNoteDeposit, nil)
 		if err != nil {
 			common.Log.Warningf("failed to dispatch %s notification for prover %s; %s", natsProverNotificationNoteDeposit, c.ID, err.Error())
 		}
@@ -1052,7 +1759,9 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 	if nullifiedIndex >= 0 && c.nullifierStore != nil {
 		common.Log.Debugf("state update nullified previous note at index %d", nullifiedIndex)
 
+		_, insertSpan := common.StartSpan(ctx, "store insert nullifier")
 		root, err := c.nullifierStore.Insert(string(nullifiedNote))
+		common.EndSpan(insertSpan, err)
 		if err != nil {
 			common.Log.Warningf("failed to insert nullifier for prover %s; %s", c.ID, err.Error())
 			return err
@@ -1069,7 +1778,7 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 				return err
 			}
 
-			_, err = c.dispatchNotification(natsProverNotificationNoteNullified)
+			_, err = c.dispatchNotification(ctx, natsProverNotificationNoteNullified, nil)
 			if err != nil {
 				common.Log.Warningf("failed to dispatch %s notification for prover %s; %s", natsProverNotificationNoteNullified, c.ID, err.Error())
 			}
@@ -1078,6 +1787,7 @@ func (c *Prover) updateState(proof string, witness map[string]interface{}) error
 		}
 	}
 
+	c.observeStoreSizes()
 	return nil
 }
 
@@ -1101,7 +1811,7 @@ func (c *Prover) exit() error {
 	var err error
 	// TODO: check to ensure an exit is possible...
 
-	_, err = c.dispatchNotification(natsProverNotificationExit)
+	_, err = c.dispatchNotification(context.Background(), natsProverNotificationExit, nil)
 	if err != nil {
 		common.Log.Warningf("failed to dispatch %s notification for prover %s; %s", natsProverNotificationExit, c.ID, err.Error())
 	}
@@ -1137,6 +1847,81 @@ func (c *Prover) validate() bool {
 		})
 	}
 
+	if c.ConstraintSpec != nil {
+		if c.Provider == nil || *c.Provider != zkp.ZKSnarkProverProviderGnark {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("constraint spec requires the gnark prover provider"),
+			})
+		} else if c.Identifier != nil && zkp.InitGnarkProverProvider(c.Curve, c.ProvingScheme).ProverFactory(*c.Identifier) != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("constraint spec prover identifier %s conflicts with a library prover", *c.Identifier)),
+			})
+		}
+
+		if _, err := c.constraintSpecCircuit(); err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(err.Error()),
+			})
+		}
+	}
+
+	if c.VerifyOnly {
+		if _, verifyingKeyOk := c.Artifacts["verifying_key"].(string); !verifyingKeyOk {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("verifying key artifact required for verify-only prover"),
+			})
+		}
+
+		if _, binaryOk := c.Artifacts["binary"]; binaryOk {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("binary artifact not supported for verify-only prover"),
+			})
+		}
+
+		if _, provingKeyOk := c.Artifacts["proving_key"]; provingKeyOk {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("proving key artifact not supported for verify-only prover"),
+			})
+		}
+
+		if c.VerifierContractDeployer != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("verifier contract deployment not supported for verify-only prover"),
+			})
+		}
+	}
+
+	if c.VerifierContractDeployer != nil {
+		if _, err := deployer.ContractDeployerFactory(*c.VerifierContractDeployer); err != nil {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(err.Error()),
+			})
+		} else if c.ProvingScheme == nil || c.Curve == nil || strings.ToLower(*c.ProvingScheme) != proverProvingSchemeGroth16 || strings.ToLower(*c.Curve) != ecc.BN254.String() {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil("verifier contract deployment requires a groth16 prover on the BN254 curve"),
+			})
+		}
+	}
+
+	if c.RollupProverID != nil {
+		c.validateRollupProver()
+	}
+
+	if c.NoteStoreProvider != nil {
+		switch *c.NoteStoreProvider {
+		case storeprovider.StoreProviderDenseMerkleTree, storeprovider.StoreProviderMiMCMerkleTree:
+			if c.NoteStoreID != nil {
+				c.Errors = append(c.Errors, &provide.Error{
+					Message: common.StringOrNil("note store provider not supported when a note store id is provided"),
+				})
+			}
+		default:
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("unsupported note store provider: %s", *c.NoteStoreProvider)),
+			})
+		}
+	}
+
 	if c.VaultID == nil {
 		if common.DefaultVault != nil {
 			c.VaultID = &common.DefaultVault.ID
@@ -1151,3 +1936,64 @@ func (c *Prover) validate() bool {
 
 	return len(c.Errors) == 0
 }
+
+// validateRollupProver validates the rollup prover and note store of a prover in rollup mode;
+// notes are stored in a mimc merkle tree, as required by the rollup circuit, by default
+func (c *Prover) validateRollupProver() {
+	if c.NoteStoreID == nil && c.NoteStoreProvider == nil {
+		c.NoteStoreProvider = common.StringOrNil(storeprovider.StoreProviderMiMCMerkleTree)
+	}
+
+	if c.NoteStoreProvider != nil && *c.NoteStoreProvider != storeprovider.StoreProviderMiMCMerkleTree {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("rollup mode requires the %s note store provider", storeprovider.StoreProviderMiMCMerkleTree)),
+		})
+	} else if c.NoteStoreID != nil {
+		store := storage.Find(*c.NoteStoreID)
+		if store == nil || store.Provider == nil || *store.Provider != storeprovider.StoreProviderMiMCMerkleTree {
+			c.Errors = append(c.Errors, &provide.Error{
+				Message: common.StringOrNil(fmt.Sprintf("rollup mode requires a note store using the %s provider", storeprovider.StoreProviderMiMCMerkleTree)),
+			})
+		}
+	}
+
+	rollupProver, err := c.rollupProver(dbconf.DatabaseConnection())
+	if err != nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(err.Error()),
+		})
+		return
+	}
+
+	if (c.ApplicationID != nil && (rollupProver.ApplicationID == nil || rollupProver.ApplicationID.String() != c.ApplicationID.String())) ||
+		(c.OrganizationID != nil && (rollupProver.OrganizationID == nil || rollupProver.OrganizationID.String() != c.OrganizationID.String())) {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("rollup prover %s not found", rollupProver.ID)),
+		})
+		return
+	}
+
+	if rollupProver.RollupProverID != nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("rollup prover %s must not be in rollup mode", rollupProver.ID)),
+		})
+	}
+
+	if rollupProver.Identifier == nil || *rollupProver.Identifier != zkp.BaselineRollupProver {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("rollup prover %s must use the %s identifier", rollupProver.ID, zkp.BaselineRollupProver)),
+		})
+	}
+
+	if c.Curve != nil && (rollupProver.Curve == nil || !strings.EqualFold(*rollupProver.Curve, *c.Curve)) {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(fmt.Sprintf("rollup prover %s curve must match the prover curve %s", rollupProver.ID, *c.Curve)),
+		})
+	}
+
+	if _, err := rollupProver.rollupBatchSize(); err != nil {
+		c.Errors = append(c.Errors, &provide.Error{
+			Message: common.StringOrNil(err.Error()),
+		})
+	}
+}
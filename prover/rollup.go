@@ -0,0 +1,505 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prover
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	dbconf "github.com/kthomas/go-db-config"
+	natsutil "github.com/kthomas/go-natsutil"
+	uuid "github.com/kthomas/go.uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/provideplatform/privacy/common"
+)
+
+const natsProverRollupSubject = "privacy.prover.rollup.pending"
+const natsProverRollupMaxInFlight = 32
+const proverRollupAckWait = time.Minute * 10
+const proverRollupMaxDeliveries = 5
+
+// rollupBatchSizeVariable is the compile-time variable of the rollup prover which determines its batch size
+const rollupBatchSizeVariable = "Leaves_count"
+
+// pendingNote is an encrypted note queued for insertion by the next rollup of its prover
+type pendingNote struct {
+	ID        uint64    `gorm:"primary_key"`
+	CreatedAt time.Time `json:"created_at"`
+	ProverID  uuid.UUID `sql:"type:uuid"`
+	Data      []byte
+}
+
+func createNatsProverRollupSubscriptions(wg *sync.WaitGroup) {
+	for i := uint64(0); i < natsutil.GetNatsConsumerConcurrency(); i++ {
+		requireNatsSubscription(natsProverRollupSubject, func() (*nats.Subscription, error) {
+			return natsutil.RequireNatsJetstreamSubscription(wg,
+				proverRollupAckWait,
+				natsProverRollupSubject,
+				natsProverRollupSubject,
+				natsProverRollupSubject,
+				drainable(consumeProverRollupMsg),
+				proverRollupAckWait,
+				natsProverRollupMaxInFlight,
+				proverRollupMaxDeliveries,
+				nil,
+			)
+		})
+	}
+}
+
+// rollupSchedulerLock is the postgres advisory lock held by the single consumer instance which schedules rollups
+const rollupSchedulerLock = 0x726f6c6c7570
+
+// scheduleRollups requests a rollup of the queued notes of each prover in rollup mode at the configured
+// interval; only the consumer instance holding the rollup scheduler lock requests rollups
+func scheduleRollups() {
+	timer := time.NewTicker(common.RollupInterval)
+	defer timer.Stop()
+
+	var leader *sql.Conn
+	for range timer.C {
+		leader = requireRollupSchedulerLeadership(leader)
+		if leader == nil {
+			continue
+		}
+
+		proverIDs, err := pendingRollupProverIDs(dbconf.DatabaseConnection())
+		if err != nil {
+			common.Log.Warningf("failed to resolve provers with pending notes; %s", err.Error())
+			continue
+		}
+
+		for _, proverID := range proverIDs {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"prover_id": proverID,
+			})
+			_, err = natsutil.NatsJetstreamPublish(natsProverRollupSubject, payload)
+			if err != nil {
+				common.Log.Warningf("failed to request rollup for prover %s; %s", proverID, err.Error())
+			}
+		}
+	}
+}
+
+// requireRollupSchedulerLeadership returns the connection holding the rollup scheduler lock, acquiring
+// the lock if it is not held by another consumer instance; returns nil if the lock is not acquired.
+// The session lock is released by postgres when the connection holding it is closed
+func requireRollupSchedulerLeadership(conn *sql.Conn) *sql.Conn {
+	ctx := context.Background()
+
+	if conn != nil {
+		err := conn.PingContext(ctx)
+		if err == nil {
+			return conn
+		}
+
+		common.Log.Warningf("lost rollup scheduler leadership; %s", err.Error())
+		conn.Close()
+	}
+
+	conn, err := dbconf.DatabaseConnection().DB().Conn(ctx)
+	if err != nil {
+		common.Log.Warningf("failed to acquire connection for rollup scheduler leadership; %s", err.Error())
+		return nil
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", rollupSchedulerLock).Scan(&acquired)
+	if err != nil || !acquired {
+		if err != nil {
+			common.Log.Warningf("failed to acquire rollup scheduler lock; %s", err.Error())
+		}
+		conn.Close()
+		return nil
+	}
+
+	common.Log.Debug("acquired rollup scheduler leadership")
+	return conn
+}
+
+// pendingRollupProverIDs returns the ids of the provers having queued notes
+func pendingRollupProverIDs(db *gorm.DB) ([]string, error) {
+	rows, err := db.Raw("SELECT DISTINCT prover_id FROM pending_notes").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proverIDs := make([]string, 0)
+	for rows.Next() {
+		var proverID string
+		err = rows.Scan(&proverID)
+		if err != nil {
+			return nil, err
+		}
+		proverIDs = append(proverIDs, proverID)
+	}
+
+	return proverIDs, nil
+}
+
+func consumeProverRollupMsg(msg *nats.Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			common.Log.Warningf("recovered during prover rollup; %s", r)
+			msg.Nak()
+		}
+	}()
+
+	common.Log.Debugf("consuming %d-byte NATS prover rollup message on subject: %s", len(msg.Data), msg.Subject)
+
+	params := map[string]interface{}{}
+	err := json.Unmarshal(msg.Data, &params)
+	if err != nil {
+		common.Log.Warningf("failed to unmarshal prover rollup message; %s", err.Error())
+		msg.Nak()
+		return
+	}
+
+	proverID, proverIDOk := params["prover_id"].(string)
+	if !proverIDOk {
+		common.Log.Warning("failed to unmarshal prover_id during rollup message handler")
+		msg.Nak()
+		return
+	}
+
+	db := dbconf.DatabaseConnection()
+
+	prover := &Prover{}
+	db.Where("id = ?", proverID).Find(&prover)
+
+	if prover == nil || prover.ID == uuid.Nil {
+		common.Log.Warningf("failed to resolve prover during rollup; prover id: %s", proverID)
+		msg.Term()
+		return
+	}
+
+	// full batches are rolled up until the queue is drained
+	for {
+		n, full, err := prover.rollup(db)
+		if err != nil {
+			common.Log.Warningf("rollup failed for prover %s; %s", prover.ID, err.Error())
+
+			// the double-spending note is dropped as it can never be rolled up; the remaining
+			// queued notes are rolled up when next scheduled
+			if doubleSpendErr, ok := err.(*rollupDoubleSpendError); ok {
+				prover.dropPendingNote(db, doubleSpendErr.noteID)
+				msg.Term()
+				return
+			}
+
+			msg.Nak()
+			return
+		}
+
+		common.Log.Debugf("rolled up %d note(s) for prover %s", n, prover.ID)
+		if !full {
+			break
+		}
+	}
+
+	msg.Ack()
+}
+
+// queueNote queues the given encrypted note for insertion by the next rollup
+func (c *Prover) queueNote(db *gorm.DB, data []byte) error {
+	note := &pendingNote{
+		ProverID: c.ID,
+		Data:     data,
+	}
+
+	result := db.Create(&note)
+	if errs := result.GetErrors(); len(errs) > 0 {
+		return fmt.Errorf("failed to queue note for rollup by prover %s; %s", c.ID, errs[0].Error())
+	}
+
+	common.Log.Debugf("queued %d-byte note for rollup by prover %s", len(data), c.ID)
+	return nil
+}
+
+// dropPendingNote removes the queued note with the given id
+func (c *Prover) dropPendingNote(db *gorm.DB, id uint64) {
+	result := db.Exec("DELETE FROM pending_notes WHERE id = ? AND prover_id = ?", id, c.ID)
+	if errs := result.GetErrors(); len(errs) > 0 {
+		common.Log.Warningf("failed to drop queued note %d of prover %s; %s", id, c.ID, errs[0].Error())
+		return
+	}
+
+	common.Log.Warningf("dropped queued note %d of prover %s", id, c.ID)
+}
+
+// rollupProver resolves the prover which proves the note insertions of the prover in rollup mode
+func (c *Prover) rollupProver(db *gorm.DB) (*Prover, error) {
+	if c.RollupProverID == nil {
+		return nil, fmt.Errorf("prover %s is not in rollup mode", c.ID)
+	}
+
+	prover := &Prover{}
+	db.Where("id = ?", c.RollupProverID).Find(&prover)
+	if prover == nil || prover.ID == uuid.Nil {
+		return nil, fmt.Errorf("failed to resolve rollup prover %s for prover %s", c.RollupProverID, c.ID)
+	}
+
+	return prover, nil
+}
+
+// rollupBatchSize returns the number of notes proven by each rollup of the rollup prover
+func (c *Prover) rollupBatchSize() (int, error) {
+	var batchSize int
+	var err error
+
+	switch val := c.compileVariables()[rollupBatchSizeVariable].(type) {
+	case float64:
+		batchSize = int(val)
+	case string:
+		batchSize, err = strconv.Atoi(val)
+	default:
+		err = fmt.Errorf("%s variable required", rollupBatchSizeVariable)
+	}
+
+	if err != nil || batchSize <= 0 {
+		return 0, fmt.Errorf("failed to resolve batch size of rollup prover %s; invalid %s variable", c.ID, rollupBatchSizeVariable)
+	}
+
+	return batchSize, nil
+}
+
+// rollupDoubleSpendError is returned by a rollup when a queued note attempts to double-spend
+type rollupDoubleSpendError struct {
+	noteID uint64
+	err    error
+}
+
+func (e *rollupDoubleSpendError) Error() string {
+	return e.err.Error()
+}
+
+// rollup inserts the next batch of queued notes into the note store of the prover in rollup mode;
+// the insertion is proven by the rollup prover, and only the batch proof, recorded as a proof of the
+// rollup prover, and the new note store root are committed and notified. Rollups of the same prover
+// are serialized, and the batch is proven before the stores are modified; a batch inserted by a rollup
+// which failed before dequeuing it is recognized by the note store, and its rollup is completed by the
+// retry. Returns the number of notes rolled up and true if the batch was full
+func (c *Prover) rollup(db *gorm.DB) (int, bool, error) {
+	rollupProver, err := c.rollupProver(db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	batchSize, err := rollupProver.rollupBatchSize()
+	if err != nil {
+		return 0, false, err
+	}
+
+	c.resolveStores()
+
+	if c.noteStore == nil {
+		return 0, false, fmt.Errorf("failed to rollup notes for prover %s; note store not resolved", c.ID)
+	}
+
+	tx := db.Begin()
+	defer tx.RollbackUnlessCommitted()
+
+	// the lock is held until the transaction ends
+	result := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", c.ID.String())
+	if errs := result.GetErrors(); len(errs) > 0 {
+		return 0, false, fmt.Errorf("failed to lock rollup of prover %s; %s", c.ID, errs[0].Error())
+	}
+
+	notes := make([]*pendingNote, 0)
+	tx.Raw("SELECT * FROM pending_notes WHERE prover_id = ? ORDER BY id LIMIT ?", c.ID, batchSize).Scan(&notes)
+	if len(notes) == 0 {
+		return 0, false, nil
+	}
+
+	ids := make([]uint64, len(notes))
+	vals := make([]string, len(notes))
+	for i, note := range notes {
+		ids[i] = note.ID
+		vals[i] = string(note.Data)
+	}
+
+	proof, err := c.noteStore.RollupProof(vals, batchSize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	size, err := c.noteStore.Size()
+	if err != nil {
+		return 0, false, err
+	}
+
+	inserted := proof.Index < uint64(size)
+	if inserted {
+		common.Log.Debugf("completing rollup of %d note(s) inserted at index %d for prover %s", len(notes), proof.Index, c.ID)
+	}
+
+	nullified, err := c.rollupNullifiedNotes(notes, proof.Index, inserted)
+	if err != nil {
+		return 0, false, err
+	}
+
+	witness := proof.Witness()
+	rollupProof, err := rollupProver.proveRollup(context.Background(), witness)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to prove rollup of %d note(s) for prover %s; %s", len(notes), c.ID, err.Error())
+	}
+
+	publicWitness, err := rollupProver.publicWitness(witness)
+	if err != nil {
+		common.Log.Warningf("failed to resolve public witness of rollup proof for prover %s; %s", c.ID, err.Error())
+	}
+
+	root, err := c.noteStore.InsertBatch(vals)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !bytes.Equal(root, proof.NewRoot) {
+		return 0, false, fmt.Errorf("rollup of %d note(s) for prover %s resulted in internal inconsistency; root %s does not match proven root %s", len(notes), c.ID, hex.EncodeToString(root), hex.EncodeToString(proof.NewRoot))
+	}
+
+	if c.nullifierStore != nil {
+		for _, note := range nullified {
+			_, err := c.nullifierStore.Insert(note)
+			if err != nil {
+				common.Log.Warningf("failed to insert nullifier for prover %s during rollup; %s", c.ID, err.Error())
+				return 0, false, err
+			}
+		}
+	}
+
+	completedAt := time.Now()
+	batchProof := &Proof{
+		ProverID:      rollupProver.ID,
+		Status:        common.StringOrNil(proofStatusCompleted),
+		Proof:         rollupProof,
+		PublicWitness: publicWitness,
+		CompletedAt:   &completedAt,
+	}
+
+	result = tx.Create(&batchProof)
+	if errs := result.GetErrors(); len(errs) > 0 {
+		return 0, false, fmt.Errorf("failed to record proof of rollup of %d note(s) for prover %s; %s", len(notes), c.ID, errs[0].Error())
+	}
+
+	result = tx.Exec("DELETE FROM pending_notes WHERE id IN (?)", ids)
+	if errs := result.GetErrors(); len(errs) > 0 {
+		return 0, false, fmt.Errorf("failed to dequeue %d rolled up note(s) for prover %s; %s", len(notes), c.ID, errs[0].Error())
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, err = c.dispatchNotification(context.Background(), natsProverNotificationNoteRollup, map[string]interface{}{
+		"rollup_prover_id": rollupProver.ID.String(),
+		"proof_id":         batchProof.ID.String(),
+		"proof":            rollupProof,
+		"root":             hex.EncodeToString(root),
+		"index":            proof.Index,
+		"size":             len(notes),
+	})
+	if err != nil {
+		common.Log.Warningf("failed to dispatch %s notification for prover %s; %s", natsProverNotificationNoteRollup, c.ID, err.Error())
+	}
+
+	c.observeStoreSizes()
+	common.Log.Debugf("rolled up %d note(s) at index %d for prover %s; root: %s", len(notes), proof.Index, c.ID, hex.EncodeToString(root))
+	return len(notes), len(notes) == batchSize, nil
+}
+
+// proveRollup generates the proof of the given rollup witness; the proof is recorded by the
+// rollup rather than in the note store of the rollup prover
+func (c *Prover) proveRollup(ctx context.Context, witness interface{}) (*string, error) {
+	c.resolveStores()
+
+	provider := c.proverProviderFactory()
+	if provider == nil {
+		return nil, fmt.Errorf("failed to resolve prover provider")
+	}
+
+	witval, err := provider.WitnessFactory(*c.Identifier, *c.Curve, witness, c.compileVariables(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.generateProof(ctx, provider, witval)
+}
+
+// rollupNullifiedNotes returns the notes nullified by the insertion of the given queued notes at the
+// given index, i.e., the note preceding the index and each inserted note but the last; a
+// *rollupDoubleSpendError identifying the spending note is returned on double-spend. Nullifiers of
+// a batch already inserted by an incomplete rollup may have been inserted by it and are skipped
+func (c *Prover) rollupNullifiedNotes(notes []*pendingNote, index uint64, inserted bool) ([]string, error) {
+	nullified := make([]string, 0)
+	if c.nullifierStore == nil {
+		return nullified, nil
+	}
+
+	// spent[i] is the note nullified by the insertion of notes[i]
+	spent := make([]string, len(notes))
+	for i := range notes {
+		if i > 0 {
+			spent[i] = string(notes[i-1].Data)
+			continue
+		}
+
+		if index > 0 {
+			last, err := c.noteStore.ValueAt(new(big.Int).SetUint64(index - 1).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			spent[i] = string(last)
+		}
+	}
+
+	for i, note := range spent {
+		if note == "" {
+			continue
+		}
+
+		exists, err := c.nullifierStore.Contains(note)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists && inserted {
+			continue
+		} else if exists {
+			doubleSpendRejections.WithLabelValues(c.metricLabels()...).Inc()
+			return nil, &rollupDoubleSpendError{
+				noteID: notes[i].ID,
+				err:    fmt.Errorf("attempt to double-spend %d-byte note for prover %s", len(note), c.ID),
+			}
+		}
+
+		nullified = append(nullified, note)
+	}
+
+	return nullified, nil
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prover
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/provideplatform/privacy/common"
+)
+
+// natsSubscription is a jetstream subscription of the consumer which can be re-established
+type natsSubscription struct {
+	subject      string
+	subscribe    func() (*nats.Subscription, error)
+	subscription *nats.Subscription
+}
+
+// NatsSubscriptionStatus is the health of the jetstream subscriptions of a subject
+type NatsSubscriptionStatus struct {
+	Subject string `json:"subject"`
+	Valid   int    `json:"valid"`
+	Total   int    `json:"total"`
+}
+
+var (
+	natsSubscriptions      []*natsSubscription
+	natsSubscriptionsMutex sync.Mutex
+
+	// draining is set when the consumer is shutting down; messages delivered while draining are nak'd
+	draining uint32
+
+	// drained is closed when the in-flight messages have been handled, or nak'd, during shutdown
+	drained = make(chan struct{})
+
+	// inFlightMsgs are the messages being handled by the consumer
+	inFlightMsgs      = map[*nats.Msg]struct{}{}
+	inFlightMsgsMutex sync.Mutex
+	inFlightMsgsWg    sync.WaitGroup
+
+	// delayedNaks are the messages to be nak'd once their redelivery delay has elapsed
+	delayedNaks      = map[*nats.Msg]*time.Timer{}
+	delayedNaksMutex sync.Mutex
+)
+
+// requireNatsSubscription establishes and registers a jetstream subscription using the given
+// subscribe function; the subscription is re-established by CheckNatsSubscriptions if it fails
+func requireNatsSubscription(subject string, subscribe func() (*nats.Subscription, error)) {
+	sub := &natsSubscription{
+		subject:   subject,
+		subscribe: subscribe,
+	}
+
+	subscription, err := subscribe()
+	if err != nil {
+		common.Log.Warningf("failed to subscribe to NATS jetstream subject: %s; %s", subject, err.Error())
+	}
+	sub.subscription = subscription
+
+	natsSubscriptionsMutex.Lock()
+	defer natsSubscriptionsMutex.Unlock()
+	natsSubscriptions = append(natsSubscriptions, sub)
+}
+
+// drainable wraps the given message handler such that in-flight messages are tracked until handled;
+// messages delivered while the consumer is draining are held until it has drained and then nak'd,
+// which blocks further deliveries to the subscription rather than immediately redelivering them
+func drainable(handler nats.MsgHandler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		inFlightMsgsMutex.Lock()
+		if atomic.LoadUint32(&draining) > 0 {
+			inFlightMsgsMutex.Unlock()
+			<-drained
+			msg.Nak()
+			return
+		}
+		inFlightMsgs[msg] = struct{}{}
+		inFlightMsgsWg.Add(1)
+		inFlightMsgsMutex.Unlock()
+
+		defer func() {
+			inFlightMsgsMutex.Lock()
+			delete(inFlightMsgs, msg)
+			inFlightMsgsWg.Done()
+			inFlightMsgsMutex.Unlock()
+		}()
+
+		handler(msg)
+	}
+}
+
+// nakWithDelay naks the given message once the given delay has elapsed, such that it is redelivered
+// after the delay without blocking the subscription in the meantime; the delay must not exceed the
+// ack wait of the subscription. Delayed naks are sent immediately when the consumer drains
+func nakWithDelay(msg *nats.Msg, delay time.Duration) {
+	delayedNaksMutex.Lock()
+	defer delayedNaksMutex.Unlock()
+
+	delayedNaks[msg] = time.AfterFunc(delay, func() {
+		delayedNaksMutex.Lock()
+		_, delayed := delayedNaks[msg]
+		delete(delayedNaks, msg)
+		delayedNaksMutex.Unlock()
+
+		if delayed {
+			msg.Nak()
+		}
+	})
+}
+
+// flushDelayedNaks immediately naks the messages for which a delayed nak is pending
+func flushDelayedNaks() {
+	delayedNaksMutex.Lock()
+	defer delayedNaksMutex.Unlock()
+
+	if len(delayedNaks) > 0 {
+		common.Log.Debugf("nak'ing %d NATS jetstream message(s) pending delayed redelivery", len(delayedNaks))
+	}
+
+	for msg, timer := range delayedNaks {
+		timer.Stop()
+		delete(delayedNaks, msg)
+		msg.Nak()
+	}
+}
+
+// CheckNatsSubscriptions re-establishes any invalid jetstream subscriptions of the consumer;
+// an error is returned if any subscription could not be re-established
+func CheckNatsSubscriptions() error {
+	if atomic.LoadUint32(&draining) > 0 {
+		return nil
+	}
+
+	natsSubscriptionsMutex.Lock()
+	defer natsSubscriptionsMutex.Unlock()
+
+	failed := 0
+	for _, sub := range natsSubscriptions {
+		if sub.subscription != nil && sub.subscription.IsValid() {
+			continue
+		}
+
+		common.Log.Debugf("re-establishing invalid NATS jetstream subscription to subject: %s", sub.subject)
+		subscription, err := sub.subscribe()
+		if err != nil {
+			common.Log.Warningf("failed to re-establish NATS jetstream subscription to subject: %s; %s", sub.subject, err.Error())
+			failed++
+			continue
+		}
+		sub.subscription = subscription
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to re-establish %d NATS jetstream subscription(s)", failed)
+	}
+
+	return nil
+}
+
+// NatsSubscriptionsStatus returns the health of the jetstream subscriptions of the consumer by subject
+func NatsSubscriptionsStatus() []*NatsSubscriptionStatus {
+	natsSubscriptionsMutex.Lock()
+	defer natsSubscriptionsMutex.Unlock()
+
+	statuses := make([]*NatsSubscriptionStatus, 0)
+	bySubject := map[string]*NatsSubscriptionStatus{}
+	for _, sub := range natsSubscriptions {
+		status, ok := bySubject[sub.subject]
+		if !ok {
+			status = &NatsSubscriptionStatus{
+				Subject: sub.subject,
+			}
+			bySubject[sub.subject] = status
+			statuses = append(statuses, status)
+		}
+
+		status.Total++
+		if sub.subscription != nil && sub.subscription.IsValid() {
+			status.Valid++
+		}
+	}
+
+	return statuses
+}
+
+// NatsSubscriptionsReady returns true if the consumer is not draining and each of its
+// jetstream subscriptions is valid
+func NatsSubscriptionsReady() bool {
+	if atomic.LoadUint32(&draining) > 0 {
+		return false
+	}
+
+	for _, status := range NatsSubscriptionsStatus() {
+		if status.Valid < status.Total {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DrainNatsSubscriptions stops handling messages delivered to the consumer and waits for in-flight
+// messages to be handled until the given timeout elapses; in-flight messages which are not handled
+// within the timeout, and messages pending a delayed nak, are nak'd such that they are promptly
+// redelivered to another consumer; the subscriptions are not unsubscribed, as unsubscribing
+// deletes the durable jetstream consumer shared by the queue group if it was created by the
+// subscription
+func DrainNatsSubscriptions(timeout time.Duration) {
+	inFlightMsgsMutex.Lock()
+	if !atomic.CompareAndSwapUint32(&draining, 0, 1) {
+		inFlightMsgsMutex.Unlock()
+		return
+	}
+	inFlightMsgsMutex.Unlock()
+	defer close(drained)
+	defer flushDelayedNaks()
+
+	done := make(chan struct{})
+	go func() {
+		inFlightMsgsWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		common.Log.Debug("drained in-flight NATS jetstream messages")
+	case <-time.After(timeout):
+		inFlightMsgsMutex.Lock()
+		defer inFlightMsgsMutex.Unlock()
+
+		common.Log.Warningf("nak'ing %d in-flight NATS jetstream message(s) not handled within %s", len(inFlightMsgs), timeout)
+		for msg := range inFlightMsgs {
+			msg.Nak()
+		}
+	}
+}
+
+// CheckHealth checks the health of the dependencies of the prover package, including the
+// availability of the privacy NATS jetstream stream
+func CheckHealth() (map[string]*common.HealthCheck, bool) {
+	return common.CheckHealth(defaultNatsStream)
+}
@@ -26,6 +26,7 @@ import (
 	dbconf "github.com/kthomas/go-db-config"
 	uuid "github.com/kthomas/go.uuid"
 	"github.com/provideplatform/privacy/store/providers/dmt"
+	"github.com/provideplatform/privacy/store/providers/mmt"
 	"github.com/provideplatform/privacy/store/providers/smt"
 )
 
@@ -35,6 +36,10 @@ const StoreProviderDenseMerkleTree = "dmt"
 // StoreProviderSparseMerkleTree sparse merkle tree storage provider
 const StoreProviderSparseMerkleTree = "smt"
 
+// StoreProviderMiMCMerkleTree mimc merkle tree storage provider; hashing and path encoding
+// match the in-circuit note membership gadget
+const StoreProviderMiMCMerkleTree = "mmt"
+
 // StoreProvider provides a common interface to interact with proof storage facilities
 type StoreProvider interface {
 	Contains(val string) (bool, error)
@@ -46,6 +51,19 @@ type StoreProvider interface {
 	CalculateKey(val string) []byte
 }
 
+// MembershipProofProvider is implemented by store providers which can prove the inclusion
+// of a value using the in-circuit note membership gadget
+type MembershipProofProvider interface {
+	MembershipProof(index uint64) (*mmt.MembershipProof, error)
+}
+
+// RollupProvider is implemented by store providers which can prove the insertion of a
+// batch of values using the in-circuit rollup gadget
+type RollupProvider interface {
+	InsertBatch(vals []string) ([]byte, error)
+	RollupProof(vals []string, batchSize int) (*mmt.RollupProof, error)
+}
+
 // InitDenseMerkleTreeStoreProvider initializes a durable merkle tree
 func InitDenseMerkleTreeStoreProvider(id uuid.UUID, curve *string) (*dmt.DMT, error) {
 	h, err := hashFactory(curve)
@@ -55,6 +73,15 @@ func InitDenseMerkleTreeStoreProvider(id uuid.UUID, curve *string) (*dmt.DMT, er
 	return dmt.InitDMT(dbconf.DatabaseConnection(), id, h)
 }
 
+// InitMiMCMerkleTreeStoreProvider initializes a durable mimc merkle tree
+func InitMiMCMerkleTreeStoreProvider(id uuid.UUID, curve *string) (*mmt.MMT, error) {
+	h, err := hashFactory(curve)
+	if err != nil {
+		return nil, err
+	}
+	return mmt.InitMMT(dbconf.DatabaseConnection(), id, h)
+}
+
 // InitSparseMerkleTreeStoreProvider initializes a sparse merkle tree
 func InitSparseMerkleTreeStoreProvider(id uuid.UUID, curve *string) (*smt.SMT, error) {
 	h, err := hashFactory(curve)
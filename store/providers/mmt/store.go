@@ -0,0 +1,254 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mmt
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/kthomas/go.uuid"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+)
+
+// MMT MiMC merkle tree; a dense merkle tree whose hashing and path encoding match
+// the in-circuit note membership gadget
+type MMT struct {
+	db     *gorm.DB
+	id     *uuid.UUID
+	mutex  *sync.Mutex
+	tree   *Tree
+	values [][]byte
+}
+
+// treeValue is the persisted representation of a value within the tree
+type treeValue struct {
+	Value string `json:"value"`
+}
+
+// InitMMT loads or initializes the MiMC merkle tree for the given store
+func InitMMT(db *gorm.DB, id uuid.UUID, h hash.Hash) (*MMT, error) {
+	tree := NewTree(h, gnark.NoteTreeDepth)
+
+	values, err := loadTree(db, id, tree)
+	if err != nil {
+		return nil, fmt.Errorf("error loading tree: %v", err)
+	}
+
+	return &MMT{
+		db:     db,
+		id:     &id,
+		mutex:  &sync.Mutex{},
+		tree:   tree,
+		values: values,
+	}, nil
+}
+
+func loadTree(db *gorm.DB, id uuid.UUID, tree *Tree) ([][]byte, error) {
+	values := make([][]byte, 0)
+
+	rows, err := db.Raw("SELECT values, root from trees WHERE store_id = ? ORDER BY id DESC LIMIT 1", id).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mimc merkle tree from store: %s; %s", id, err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var valuesRaw json.RawMessage
+		var root string
+		err = rows.Scan(&valuesRaw, &root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan the store for mimc merkle tree; %s", err.Error())
+		}
+
+		var vals []*treeValue
+		err = json.Unmarshal(valuesRaw, &vals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal mimc merkle tree for store %s; %s", id, err.Error())
+		}
+
+		for _, v := range vals {
+			val, err := base64.RawStdEncoding.DecodeString(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode mimc merkle tree value for store %s; %s", id, err.Error())
+			}
+			values = append(values, val)
+		}
+
+		err = tree.AddAll(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import mimc merkle tree for store %s; %s", id, err.Error())
+		}
+
+		if hex.EncodeToString(tree.Root()) != root {
+			return nil, fmt.Errorf("failed to verify mimc merkle tree for store %s", id)
+		}
+
+		common.Log.Debugf("imported mimc merkle tree for store %s; root: %s", id, root)
+	}
+
+	return values, nil
+}
+
+// commit the current state of the mimc merkle tree to the database
+func (s *MMT) commit() error {
+	vals := make([]*treeValue, len(s.values))
+	for i := range s.values {
+		vals[i] = &treeValue{
+			Value: base64.RawStdEncoding.EncodeToString(s.values[i]),
+		}
+	}
+
+	values, _ := json.Marshal(vals)
+	root := s.tree.Root()
+
+	db := s.db.Exec("INSERT INTO trees (store_id, nodes, values, root) VALUES (?, ?, ?, ?)", s.id, []byte("{}"), values, hex.EncodeToString(root))
+	if db.RowsAffected == 0 {
+		return fmt.Errorf("failed to persist value within mimc merkle tree: %s", s.id)
+	}
+
+	common.Log.Debugf("committed state (%d values) within mimc merkle tree %s; root: %s", len(s.values), s.id, hex.EncodeToString(root))
+	return nil
+}
+
+func (s *MMT) Contains(val string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.tree.Contains([]byte(val)), nil
+}
+
+func (s *MMT) Get(key []byte) (val []byte, err error) {
+	i := new(big.Int).SetBytes(key).Uint64()
+	if len(s.values) == 0 {
+		return nil, fmt.Errorf("failed to resolve value within mimc merkle tree at index %d; tree is empty", i)
+	}
+
+	if i >= uint64(len(s.values)) {
+		return nil, fmt.Errorf("failed to resolve value within mimc merkle tree at index %d; index out of bounds", i)
+	}
+
+	return s.values[i], nil
+}
+
+func (s *MMT) Height() int {
+	return s.tree.Depth()
+}
+
+func (s *MMT) Insert(val string) (root []byte, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index, err := s.tree.Add([]byte(val))
+	if err != nil {
+		return nil, err
+	}
+	s.values = append(s.values, []byte(val))
+
+	err = s.commit()
+	if err != nil {
+		return nil, err
+	}
+
+	common.Log.Debugf("inserted value in mimc merkle tree at index %d; current root: %s", index, hex.EncodeToString(s.tree.Root()))
+	return s.tree.Root(), nil
+}
+
+// InsertBatch inserts the given values and commits the resulting state once; values which
+// are already the last values of the tree, i.e., a batch inserted by an incomplete rollup,
+// are not inserted again
+func (s *MMT) InsertBatch(vals []string) (root []byte, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tree.Inserted(batchValues(vals)) {
+		common.Log.Debugf("%d values already inserted in mimc merkle tree; current root: %s", len(vals), hex.EncodeToString(s.tree.Root()))
+		return s.tree.Root(), nil
+	}
+
+	if uint64(s.tree.Size()+len(vals)) > uint64(1)<<uint(s.tree.Depth()) {
+		return nil, fmt.Errorf("failed to insert %d values in mimc merkle tree; tree of depth %d is full", len(vals), s.tree.Depth())
+	}
+
+	for _, val := range vals {
+		_, err := s.tree.Add([]byte(val))
+		if err != nil {
+			return nil, err
+		}
+		s.values = append(s.values, []byte(val))
+	}
+
+	err = s.commit()
+	if err != nil {
+		return nil, err
+	}
+
+	common.Log.Debugf("inserted %d values in mimc merkle tree; current root: %s", len(vals), hex.EncodeToString(s.tree.Root()))
+	return s.tree.Root(), nil
+}
+
+// RollupProof returns the proof of the insertion of the given values in a batch of the given size;
+// when the values are already the last values of the tree, the proof of their insertion is returned
+func (s *MMT) RollupProof(vals []string, batchSize int) (*RollupProof, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_vals := batchValues(vals)
+	if s.tree.Inserted(_vals) {
+		return s.tree.RollupAt(uint64(s.tree.Size()-len(_vals)), _vals, batchSize)
+	}
+
+	return s.tree.Rollup(_vals, batchSize)
+}
+
+func batchValues(vals []string) [][]byte {
+	_vals := make([][]byte, len(vals))
+	for i := range vals {
+		_vals[i] = []byte(vals[i])
+	}
+	return _vals
+}
+
+// MembershipProof returns the membership proof for the value at the given index
+func (s *MMT) MembershipProof(index uint64) (*MembershipProof, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.tree.MembershipProof(index)
+}
+
+func (s *MMT) Root() (root *string, err error) {
+	if s.tree.Root() == nil || len(s.tree.Root()) == 0 {
+		return nil, errors.New("tree does not contain a valid root")
+	}
+	return common.StringOrNil(hex.EncodeToString(s.tree.Root())), nil
+}
+
+func (s *MMT) Size() int {
+	return len(s.values)
+}
+
+func (s *MMT) CalculateKey(val string) []byte {
+	return []byte{}
+}
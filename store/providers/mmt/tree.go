@@ -0,0 +1,352 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mmt
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// Tree is an append-only, fixed-depth merkle tree of MiMC commitments; leaves are the MiMC
+// hash of each value, parents are MiMC(left || right) and empty subtrees hash to zero leaves,
+// matching the in-circuit membership gadget
+type Tree struct {
+	depth  int
+	hash   hash.Hash
+	leaves [][]byte
+	levels [][][]byte
+	zeros  [][]byte // hash of an empty subtree at each level
+}
+
+// MembershipProof proves the inclusion of a commitment in the tree; Path contains
+// the sibling at each level, from the leaves to the root
+type MembershipProof struct {
+	Root       []byte
+	Commitment []byte
+	Index      uint64
+	Path       [][]byte
+}
+
+// NewTree initializes an empty tree of the given depth using the given MiMC hash function
+func NewTree(h hash.Hash, depth int) *Tree {
+	tree := &Tree{
+		depth:  depth,
+		hash:   h,
+		leaves: make([][]byte, 0),
+		zeros:  make([][]byte, depth+1),
+	}
+
+	tree.zeros[0] = make([]byte, h.Size())
+	for i := 0; i < depth; i++ {
+		tree.zeros[i+1] = tree.nodeHash(tree.zeros[i], tree.zeros[i])
+	}
+
+	tree.rebuild()
+	return tree
+}
+
+// Commitment returns the leaf commitment of the given value
+func (t *Tree) Commitment(val []byte) []byte {
+	t.hash.Reset()
+	t.hash.Write(val)
+	return t.hash.Sum(nil)
+}
+
+// Add the given value to the tree and return its index
+func (t *Tree) Add(val []byte) (uint64, error) {
+	if uint64(len(t.leaves)) >= uint64(1)<<uint(t.depth) {
+		return 0, fmt.Errorf("tree of depth %d is full", t.depth)
+	}
+
+	t.leaves = append(t.leaves, t.Commitment(val))
+	index := uint64(len(t.leaves) - 1)
+	t.update(index)
+
+	return index, nil
+}
+
+// AddAll adds the given values to the tree, rebuilding the tree once, i.e. when loading
+// the persisted values of a tree
+func (t *Tree) AddAll(vals [][]byte) error {
+	if uint64(len(t.leaves)+len(vals)) > uint64(1)<<uint(t.depth) {
+		return fmt.Errorf("tree of depth %d cannot contain %d additional leaves", t.depth, len(vals))
+	}
+
+	for _, val := range vals {
+		t.leaves = append(t.leaves, t.Commitment(val))
+	}
+	t.rebuild()
+
+	return nil
+}
+
+// Contains returns true if the commitment of the given value is a leaf of the tree
+func (t *Tree) Contains(val []byte) bool {
+	commitment := t.Commitment(val)
+	for _, leaf := range t.leaves {
+		if bytes.Equal(leaf, commitment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inserted returns true if the commitments of the given values are the last leaves of the tree
+func (t *Tree) Inserted(vals [][]byte) bool {
+	if len(vals) == 0 || len(vals) > len(t.leaves) {
+		return false
+	}
+
+	offset := len(t.leaves) - len(vals)
+	for i, val := range vals {
+		if !bytes.Equal(t.leaves[offset+i], t.Commitment(val)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Root returns the current root of the tree
+func (t *Tree) Root() []byte {
+	return t.levels[t.depth][0]
+}
+
+// Size returns the number of leaves in the tree
+func (t *Tree) Size() int {
+	return len(t.leaves)
+}
+
+// MembershipProof returns the membership proof for the leaf at the given index
+func (t *Tree) MembershipProof(index uint64) (*MembershipProof, error) {
+	if index >= uint64(len(t.leaves)) {
+		return nil, fmt.Errorf("failed to resolve membership proof at index %d; index out of bounds", index)
+	}
+
+	return &MembershipProof{
+		Root:       t.Root(),
+		Commitment: t.leaves[index],
+		Index:      index,
+		Path:       t.path(index),
+	}, nil
+}
+
+// Rollup returns the proof of the insertion of the given values, padded with empty leaves
+// to the given batch size; the tree is not modified
+func (t *Tree) Rollup(vals [][]byte, batchSize int) (*RollupProof, error) {
+	return t.RollupAt(uint64(len(t.leaves)), vals, batchSize)
+}
+
+// RollupAt returns the proof of the insertion of the given values at the given index, i.e.,
+// into the tree containing only the leaves preceding the index; the tree is not modified
+func (t *Tree) RollupAt(index uint64, vals [][]byte, batchSize int) (*RollupProof, error) {
+	if len(vals) == 0 || len(vals) > batchSize {
+		return nil, fmt.Errorf("failed to resolve rollup proof; %d values provided for batch size %d", len(vals), batchSize)
+	}
+
+	if index > uint64(len(t.leaves)) {
+		return nil, fmt.Errorf("failed to resolve rollup proof at index %d; index out of bounds", index)
+	}
+
+	if index+uint64(batchSize) > uint64(1)<<uint(t.depth) {
+		return nil, fmt.Errorf("failed to resolve rollup proof; tree of depth %d cannot contain %d additional leaves", t.depth, batchSize)
+	}
+
+	tree := &Tree{
+		depth:  t.depth,
+		hash:   t.hash,
+		leaves: append(make([][]byte, 0, int(index)+len(vals)), t.leaves[:index]...),
+		zeros:  t.zeros,
+	}
+	tree.rebuild()
+
+	proof := &RollupProof{
+		OldRoot: tree.Root(),
+		Index:   index,
+		Leaves:  make([][]byte, batchSize),
+		Paths:   make([][][]byte, batchSize),
+	}
+
+	for i := 0; i < batchSize; i++ {
+		proof.Paths[i] = tree.path(index + uint64(i))
+
+		if i < len(vals) {
+			_, err := tree.Add(vals[i])
+			if err != nil {
+				return nil, err
+			}
+			proof.Leaves[i] = tree.leaves[len(tree.leaves)-1]
+		} else {
+			proof.Leaves[i] = t.zeros[0]
+		}
+	}
+
+	proof.NewRoot = tree.Root()
+	return proof, nil
+}
+
+// Verify returns true if the given membership proof resolves to the given root
+func (t *Tree) Verify(root []byte, proof *MembershipProof) bool {
+	if len(proof.Path) != t.depth {
+		return false
+	}
+
+	node := proof.Commitment
+	for level, sibling := range proof.Path {
+		if (proof.Index>>uint(level))&1 == 1 {
+			node = t.nodeHash(sibling, node)
+		} else {
+			node = t.nodeHash(node, sibling)
+		}
+	}
+
+	return bytes.Equal(node, root)
+}
+
+// RollupProof proves the tree with root OldRoot has root NewRoot after the insertion of
+// Leaves at consecutive indices starting at Index; Paths contains the siblings of each leaf
+// at the time of its insertion and empty leaves pad partial batches
+type RollupProof struct {
+	OldRoot []byte
+	NewRoot []byte
+	Index   uint64
+	Leaves  [][]byte
+	Paths   [][][]byte
+}
+
+// Witness returns the rollup proof as decimal field elements suitable for use as the
+// OldRoot, NewRoot, Index, Leaves and Paths of a rollup circuit witness
+func (p *RollupProof) Witness() map[string]interface{} {
+	leaves := make([]interface{}, len(p.Leaves))
+	paths := make([]interface{}, len(p.Paths))
+	for i := range p.Leaves {
+		leaves[i] = new(big.Int).SetBytes(p.Leaves[i]).String()
+
+		path := make([]interface{}, len(p.Paths[i]))
+		for j := range p.Paths[i] {
+			path[j] = new(big.Int).SetBytes(p.Paths[i][j]).String()
+		}
+		paths[i] = path
+	}
+
+	return map[string]interface{}{
+		"OldRoot": new(big.Int).SetBytes(p.OldRoot).String(),
+		"NewRoot": new(big.Int).SetBytes(p.NewRoot).String(),
+		"Index":   p.Index,
+		"Leaves":  leaves,
+		"Paths":   paths,
+	}
+}
+
+// Witness returns the membership proof as decimal field elements suitable for use as
+// the Root, Commitment, Index and Path of a membership circuit witness
+func (p *MembershipProof) Witness() map[string]interface{} {
+	path := make([]interface{}, len(p.Path))
+	for i := range p.Path {
+		path[i] = new(big.Int).SetBytes(p.Path[i]).String()
+	}
+
+	return map[string]interface{}{
+		"Root":       new(big.Int).SetBytes(p.Root).String(),
+		"Commitment": new(big.Int).SetBytes(p.Commitment).String(),
+		"Index":      p.Index,
+		"Path":       path,
+	}
+}
+
+// rebuild recalculates each level of the tree from the leaves; missing right children
+// are replaced by the hash of the empty subtree at the same level
+func (t *Tree) rebuild() {
+	t.levels = make([][][]byte, t.depth+1)
+	t.levels[0] = t.leaves
+
+	for level := 0; level < t.depth; level++ {
+		nodes := t.levels[level]
+		parents := make([][]byte, (len(nodes)+1)/2)
+
+		for i := range parents {
+			left := nodes[i*2]
+			right := t.zeros[level]
+			if i*2+1 < len(nodes) {
+				right = nodes[i*2+1]
+			}
+			parents[i] = t.nodeHash(left, right)
+		}
+
+		t.levels[level+1] = parents
+	}
+
+	if len(t.levels[t.depth]) == 0 {
+		t.levels[t.depth] = [][]byte{t.zeros[t.depth]}
+	}
+}
+
+// update recalculates the nodes on the path from the leaf at the given index to the root;
+// missing right children are replaced by the hash of the empty subtree at the same level
+func (t *Tree) update(index uint64) {
+	t.levels[0] = t.leaves
+
+	i := index
+	for level := 0; level < t.depth; level++ {
+		nodes := t.levels[level]
+		parent := i >> 1
+
+		left := nodes[parent*2]
+		right := t.zeros[level]
+		if parent*2+1 < uint64(len(nodes)) {
+			right = nodes[parent*2+1]
+		}
+
+		node := t.nodeHash(left, right)
+		if parent < uint64(len(t.levels[level+1])) {
+			t.levels[level+1][parent] = node
+		} else {
+			t.levels[level+1] = append(t.levels[level+1], node)
+		}
+
+		i = parent
+	}
+}
+
+// path returns the sibling at each level of the leaf at the given index, which may be empty
+func (t *Tree) path(index uint64) [][]byte {
+	path := make([][]byte, t.depth)
+	i := index
+	for level := 0; level < t.depth; level++ {
+		sibling := i ^ 1
+		if sibling < uint64(len(t.levels[level])) {
+			path[level] = t.levels[level][sibling]
+		} else {
+			path[level] = t.zeros[level]
+		}
+		i >>= 1
+	}
+	return path
+}
+
+func (t *Tree) nodeHash(left, right []byte) []byte {
+	t.hash.Reset()
+	t.hash.Write(left)
+	t.hash.Write(right)
+	return t.hash.Sum(nil)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/provideplatform/privacy/common"
 	"github.com/provideplatform/privacy/state"
 	proofstorage "github.com/provideplatform/privacy/store/providers"
+	"github.com/provideplatform/privacy/store/providers/mmt"
 	provide "github.com/provideplatform/provide-go/api"
 )
 
@@ -56,6 +57,8 @@ func (s *Store) storeProviderFactory() (proofstorage.StoreProvider, error) {
 		s.provider, err = proofstorage.InitDenseMerkleTreeStoreProvider(s.ID, s.Curve)
 	case proofstorage.StoreProviderSparseMerkleTree:
 		s.provider, err = proofstorage.InitSparseMerkleTreeStoreProvider(s.ID, s.Curve)
+	case proofstorage.StoreProviderMiMCMerkleTree:
+		s.provider, err = proofstorage.InitMiMCMerkleTreeStoreProvider(s.ID, s.Curve)
 	default:
 		return nil, fmt.Errorf("failed to initialize store provider; unknown provider: %s", *s.Provider)
 	}
@@ -211,6 +214,67 @@ func (s *Store) ValueAt(key []byte) ([]byte, error) {
 	return val, nil
 }
 
+// MembershipProof returns the membership proof for the value at the given index
+func (s *Store) MembershipProof(index uint64) (*mmt.MembershipProof, error) {
+	provider, err := s.storeProviderFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve membership proof in store %s; %s", s.ID, err.Error())
+	}
+
+	prover, proverOk := provider.(proofstorage.MembershipProofProvider)
+	if !proverOk {
+		return nil, fmt.Errorf("failed to resolve membership proof in store %s; membership proofs not supported by %s provider", s.ID, *s.Provider)
+	}
+
+	proof, err := prover.MembershipProof(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve membership proof in store %s; %s", s.ID, err.Error())
+	}
+
+	return proof, nil
+}
+
+// RollupProof returns the proof of the insertion of the given values in a batch of the given size
+func (s *Store) RollupProof(vals []string, batchSize int) (*mmt.RollupProof, error) {
+	provider, err := s.storeProviderFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve rollup proof in store %s; %s", s.ID, err.Error())
+	}
+
+	prover, proverOk := provider.(proofstorage.RollupProvider)
+	if !proverOk {
+		return nil, fmt.Errorf("failed to resolve rollup proof in store %s; rollups not supported by %s provider", s.ID, *s.Provider)
+	}
+
+	proof, err := prover.RollupProof(vals, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve rollup proof in store %s; %s", s.ID, err.Error())
+	}
+
+	return proof, nil
+}
+
+// InsertBatch inserts the given values in a single commit and returns the resulting root;
+// a batch already inserted by an incomplete rollup is not inserted again
+func (s *Store) InsertBatch(vals []string) (root []byte, err error) {
+	provider, err := s.storeProviderFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert batch in store %s; %s", s.ID, err.Error())
+	}
+
+	prover, proverOk := provider.(proofstorage.RollupProvider)
+	if !proverOk {
+		return nil, fmt.Errorf("failed to insert batch in store %s; rollups not supported by %s provider", s.ID, *s.Provider)
+	}
+
+	root, err = prover.InsertBatch(vals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert batch in store %s; %s", s.ID, err.Error())
+	}
+
+	return root, nil
+}
+
 // CalculateKey returns the key for a corresponding value
 func (s *Store) CalculateKey(val string) (key []byte, err error) {
 	provider, err := s.storeProviderFactory()
@@ -0,0 +1,170 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+const batchSize = 5
+
+// proveBatch generates proofs of knowledge of n preimages and returns the verifying key, proofs and public witnesses
+func proveBatch(t *testing.T, provider *zkp.GnarkProverProvider, curve string, h gnarkhash.Hash, n int) ([]byte, [][]byte, [][]byte) {
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	proofs := make([][]byte, n)
+	publicWitnesses := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		hFunc := h.New()
+		hFunc.Write(new(big.Int).SetInt64(int64(i + 1)).FillBytes(make([]byte, hFunc.BlockSize())))
+		hash := hFunc.Sum(nil)
+
+		witval, err := provider.WitnessFactory(zkp.PreimageHashProver, curve, map[string]interface{}{
+			"Preimage": fmt.Sprintf("%d", i+1),
+			"Hash":     new(big.Int).SetBytes(hash).String(),
+		}, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+		}
+
+		proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove preimage hash witness %d; %s", i, err.Error())
+		}
+
+		proofs[i] = testutil.WriteTo(t, proof)
+		publicWitnesses[i], err = provider.PublicWitness(witval)
+		if err != nil {
+			t.Fatalf("failed to resolve public witness %d; %s", i, err.Error())
+		}
+	}
+
+	return testutil.WriteTo(t, vk), proofs, publicWitnesses
+}
+
+func requireBatchResults(t *testing.T, results []error, invalid ...int) {
+	if len(results) != batchSize {
+		t.Fatalf("expected %d batch verification results; got %d", batchSize, len(results))
+	}
+
+	for i, err := range results {
+		expectInvalid := false
+		for _, j := range invalid {
+			expectInvalid = expectInvalid || i == j
+		}
+
+		if expectInvalid && err == nil {
+			t.Fatalf("expected proof %d not to be verified", i)
+		} else if !expectInvalid && err != nil {
+			t.Fatalf("expected proof %d to be verified; %s", i, err.Error())
+		}
+	}
+}
+
+func testBatchVerify(t *testing.T, curve string, h gnarkhash.Hash) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil(curve), common.StringOrNil("groth16"))
+	vk, proofs, publicWitnesses := proveBatch(t, provider, curve, h, batchSize)
+
+	results, err := provider.BatchVerify(proofs, vk, publicWitnesses, nil)
+	if err != nil {
+		t.Fatalf("failed to batch verify proofs; %s", err.Error())
+	}
+	requireBatchResults(t, results)
+
+	// each proof is verified against the public witness of another proof
+	swapped := append([][]byte{publicWitnesses[1], publicWitnesses[0]}, publicWitnesses[2:]...)
+	results, err = provider.BatchVerify(proofs, vk, swapped, nil)
+	if err != nil {
+		t.Fatalf("failed to batch verify proofs; %s", err.Error())
+	}
+	requireBatchResults(t, results, 0, 1)
+
+	malformed := append([][]byte{}, proofs...)
+	malformed[3] = []byte{0x01}
+	results, err = provider.BatchVerify(malformed, vk, publicWitnesses, nil)
+	if err != nil {
+		t.Fatalf("failed to batch verify proofs; %s", err.Error())
+	}
+	requireBatchResults(t, results, 3)
+
+	_, err = provider.BatchVerify(proofs, vk, publicWitnesses[1:], nil)
+	if err == nil {
+		t.Fatalf("expected batch verification with mismatched public witnesses to fail")
+	}
+}
+
+func TestBatchVerifyGroth16BN254(t *testing.T) {
+	testBatchVerify(t, "BN254", gnarkhash.MIMC_BN254)
+}
+
+func TestBatchVerifyGroth16BLS12377(t *testing.T) {
+	testBatchVerify(t, "BLS12_377", gnarkhash.MIMC_BLS12_377)
+}
+
+func TestWitnessesAreIndependent(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	if provider.ProverFactory(zkp.PreimageHashProver) == provider.ProverFactory(zkp.PreimageHashProver) {
+		t.Fatalf("expected a new circuit instance for each witness")
+	}
+
+	witnesses := make([]interface{}, 2)
+	for i := range witnesses {
+		witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+			"Preimage": fmt.Sprintf("%d", i+1),
+			"Hash":     fmt.Sprintf("%d", i+2),
+		}, nil, true)
+		if err != nil {
+			t.Fatalf("failed to build witness %d; %s", i, err.Error())
+		}
+		witnesses[i] = witval
+	}
+
+	// the public values of the first witness are not overwritten by the second
+	first, err := provider.PublicWitness(witnesses[0])
+	if err != nil {
+		t.Fatalf("failed to resolve public witness; %s", err.Error())
+	}
+
+	second, err := provider.PublicWitness(witnesses[1])
+	if err != nil {
+		t.Fatalf("failed to resolve public witness; %s", err.Error())
+	}
+
+	if bytes.Equal(first, second) {
+		t.Fatalf("expected public witnesses of distinct inputs to differ")
+	}
+}
@@ -0,0 +1,325 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := common.NewCache(10)
+
+	cache.Add("a", "a", 4)
+	cache.Add("b", "b", 4)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+
+	cache.Add("c", "c", 4)
+	if _, ok := cache.Get("b"); ok {
+		t.Fatalf("expected least recently used entry b to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	if cache.Size() != 8 || cache.Len() != 2 {
+		t.Fatalf("expected 2 entries of 8 bytes; got %d entries of %d bytes", cache.Len(), cache.Size())
+	}
+
+	if cache.Add("d", "d", 11) {
+		t.Fatalf("expected entry larger than the cache capacity not to be cached")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries; got %d", cache.Len())
+	}
+
+	cache.Add("a", "a", 2)
+	if cache.Size() != 6 {
+		t.Fatalf("expected replaced entry to be resized; got %d bytes", cache.Size())
+	}
+}
+
+func TestCacheRemovePrefix(t *testing.T) {
+	cache := common.NewCache(1024)
+	cache.Add("prover/1/pk", 1, 1)
+	cache.Add("prover/1/vk", 2, 1)
+	cache.Add("prover/2/pk", 3, 1)
+
+	if removed := cache.RemovePrefix("prover/1/"); removed != 2 {
+		t.Fatalf("expected 2 entries to be removed; %d removed", removed)
+	}
+	if _, ok := cache.Get("prover/2/pk"); !ok {
+		t.Fatalf("expected entry of another prover to be cached")
+	}
+	if cache.Size() != 1 {
+		t.Fatalf("expected 1 byte cached; got %d", cache.Size())
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	cache := common.NewCache(0)
+	if cache.Add("a", "a", 1) {
+		t.Fatalf("expected disabled cache not to retain entries")
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Fatalf("expected disabled cache not to retain entries")
+	}
+}
+
+func TestProverProviderCachesDecodedArtifacts(t *testing.T) {
+	common.ArtifactCache = common.NewCache(1024 * 1024 * 1024)
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+	provider.SetCacheScope("prover/test/")
+	provider.SetArtifactIDs("proving-key-secret-id", "verifying-key-secret-id", "")
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(3).FillBytes(make([]byte, hFunc.BlockSize())))
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "3",
+		"Hash":     new(big.Int).SetBytes(hFunc.Sum(nil)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+		}
+
+		err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify preimage hash proof; %s", err.Error())
+		}
+
+		// the decoded r1cs, proving key and verifying key are reused
+		if common.ArtifactCache.Len() != 3 {
+			t.Fatalf("expected 3 decoded artifacts to be cached; got %d", common.ArtifactCache.Len())
+		}
+	}
+
+	// entries are accounted by their decoded in-memory size rather than their encoded length
+	if common.ArtifactCache.Size() < int64(len(testutil.WriteTo(t, r1cs))+len(testutil.WriteTo(t, pk))+len(testutil.WriteTo(t, vk))) {
+		t.Fatalf("expected cached artifacts to be accounted by their in-memory size; %d bytes", common.ArtifactCache.Size())
+	}
+
+	// a recompiled constraint system is identified within the scope by its digest
+	recompiled, err := provider.Compile(provider.ProverFactory(zkp.PurchaseOrderProver))
+	if err != nil {
+		t.Fatalf("failed to compile purchase order prover; %s", err.Error())
+	}
+
+	provider.Solve(testutil.WriteTo(t, recompiled), witval)
+	if common.ArtifactCache.Len() != 4 {
+		t.Fatalf("expected recompiled constraint system to be cached separately; got %d cached artifacts", common.ArtifactCache.Len())
+	}
+
+	if removed := common.ArtifactCache.RemovePrefix("prover/test/"); removed != 4 {
+		t.Fatalf("expected cached artifacts to be removed by scope; %d removed", removed)
+	}
+}
+
+func TestProverProviderCachesIdentifiedArtifactsOnly(t *testing.T) {
+	common.ArtifactCache = common.NewCache(1024 * 1024 * 1024)
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(3).FillBytes(make([]byte, hFunc.BlockSize())))
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "3",
+		"Hash":     new(big.Int).SetBytes(hFunc.Sum(nil)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+	}
+
+	verify := func(t *testing.T, provider *zkp.GnarkProverProvider, proof, vk []byte, witval interface{}) {
+		err := provider.Verify(context.Background(), proof, vk, witval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify preimage hash proof; %s", err.Error())
+		}
+	}
+
+	// artifacts of a provider without a cache scope are not cached
+	verify(t, provider, testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval)
+	if common.ArtifactCache.Len() != 0 {
+		t.Fatalf("expected artifacts of an unscoped provider not to be cached; got %d", common.ArtifactCache.Len())
+	}
+
+	// the verifying key is cached by its vault secret id
+	provider.SetCacheScope("prover/test/")
+	verify(t, provider, testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval)
+	if common.ArtifactCache.Len() != 0 {
+		t.Fatalf("expected verifying key without a secret id not to be cached; got %d", common.ArtifactCache.Len())
+	}
+
+	provider.SetArtifactIDs("", "verifying-key-secret-id", "")
+	verify(t, provider, testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval)
+	if _, ok := common.ArtifactCache.Get("prover/test/vk/verifying-key-secret-id"); !ok {
+		t.Fatalf("expected verifying key to be cached by prover and secret id")
+	}
+}
+
+func TestProverProviderResolvesArtifactsOnCacheMiss(t *testing.T) {
+	common.ArtifactCache = common.NewCache(1024 * 1024 * 1024)
+
+	setupProvider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+	r1cs, err := setupProvider.Compile(setupProvider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := setupProvider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	secrets := map[string][]byte{
+		"proving-key-secret-id":   testutil.WriteTo(t, pk),
+		"verifying-key-secret-id": testutil.WriteTo(t, vk),
+	}
+	fetched := map[string]int{}
+
+	// a provider is initialized for each operation of a prover, i.e. as by the prover provider factory
+	providerFactory := func() *zkp.GnarkProverProvider {
+		provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+		provider.SetCacheScope("prover/test/")
+		provider.SetArtifactIDs("proving-key-secret-id", "verifying-key-secret-id", "")
+		provider.SetArtifactSource(func(id string) ([]byte, error) {
+			fetched[id]++
+			return secrets[id], nil
+		})
+		return provider
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(3).FillBytes(make([]byte, hFunc.BlockSize())))
+	hash := new(big.Int).SetBytes(hFunc.Sum(nil)).String()
+
+	for i := 0; i < 2; i++ {
+		provider := providerFactory()
+
+		witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+			"Preimage": "3",
+			"Hash":     hash,
+		}, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+		}
+
+		proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), nil, witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+		}
+
+		err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), nil, witval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify preimage hash proof; %s", err.Error())
+		}
+
+		publicWitness, err := provider.PublicWitness(witval)
+		if err != nil {
+			t.Fatalf("failed to resolve public witness; %s", err.Error())
+		}
+
+		results, err := provider.BatchVerify([][]byte{testutil.WriteTo(t, proof)}, nil, [][]byte{publicWitness}, nil)
+		if err != nil || results[0] != nil {
+			t.Fatalf("failed to batch verify preimage hash proof; %v; %v", err, results)
+		}
+
+		// the keys are resolved from the artifact source on the first cache miss only
+		if fetched["proving-key-secret-id"] != 1 || fetched["verifying-key-secret-id"] != 1 {
+			t.Fatalf("expected each key to be resolved once; resolved %v", fetched)
+		}
+	}
+
+	common.ArtifactCache.RemovePrefix("prover/test/")
+	_, err = providerFactory().Prove(context.Background(), testutil.WriteTo(t, r1cs), nil, nil, nil)
+	if err == nil {
+		t.Fatalf("expected proving without a witness to fail")
+	}
+	if fetched["proving-key-secret-id"] != 2 {
+		t.Fatalf("expected evicted proving key to be resolved again; resolved %d time(s)", fetched["proving-key-secret-id"])
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	type node struct {
+		Values []uint64
+		Name   string
+		Next   *node
+	}
+
+	shared := &node{Values: make([]uint64, 1024), Name: "shared"}
+	val := &node{
+		Values: make([]uint64, 16),
+		Next:   shared,
+	}
+
+	size := common.SizeOf(val)
+	if size < 8*(1024+16)+int64(len("shared")) {
+		t.Fatalf("expected size to include the referenced values; got %d", size)
+	}
+
+	// memory referenced more than once is counted once
+	if common.SizeOf([]*node{shared, shared}) >= 2*common.SizeOf(shared) {
+		t.Fatalf("expected shared memory to be counted once")
+	}
+
+	if common.SizeOf(nil) != 0 {
+		t.Fatalf("expected size of nil to be zero")
+	}
+}
@@ -0,0 +1,183 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+// invoiceCircuit composes the comparator gadgets, i.e., invoice total <= PO total and the
+// invoice date lies within the PO validity period
+type invoiceCircuit struct {
+	InvoiceTotal frontend.Variable
+	InvoiceDate  frontend.Variable
+	POTotal      frontend.Variable `gnark:",public"`
+	POStart      frontend.Variable `gnark:",public"`
+	POEnd        frontend.Variable `gnark:",public"`
+}
+
+func (circuit *invoiceCircuit) Define(api frontend.API) error {
+	gnark.AssertIsLessOrEqual(api, circuit.InvoiceTotal, circuit.POTotal, 64)
+	gnark.AssertIsInRange(api, circuit.InvoiceDate, circuit.POStart, circuit.POEnd, 40)
+	return nil
+}
+
+func TestComparatorGadgets(t *testing.T) {
+	valid := &invoiceCircuit{
+		InvoiceTotal: 9500,
+		InvoiceDate:  1660000000,
+		POTotal:      10000,
+		POStart:      1650000000,
+		POEnd:        1670000000,
+	}
+	err := test.IsSolved(&invoiceCircuit{}, valid, ecc.BN254, backend.GROTH16)
+	if err != nil {
+		t.Fatalf("expected valid invoice witness to be satisfied; %s", err.Error())
+	}
+
+	for _, invalid := range []*invoiceCircuit{
+		{InvoiceTotal: 10001, InvoiceDate: 1660000000, POTotal: 10000, POStart: 1650000000, POEnd: 1670000000},
+		{InvoiceTotal: 9500, InvoiceDate: 1640000000, POTotal: 10000, POStart: 1650000000, POEnd: 1670000000},
+		{InvoiceTotal: 9500, InvoiceDate: 1680000000, POTotal: 10000, POStart: 1650000000, POEnd: 1670000000},
+		{InvoiceTotal: "0x10000000000000000", InvoiceDate: 1660000000, POTotal: 10000, POStart: 1650000000, POEnd: 1670000000},
+	} {
+		err := test.IsSolved(&invoiceCircuit{}, invalid, ecc.BN254, backend.GROTH16)
+		if err == nil {
+			t.Fatalf("expected invalid invoice witness %v to be unsatisfied", invalid)
+		}
+	}
+}
+
+func TestComparatorProvers(t *testing.T) {
+	for _, tc := range []struct {
+		identifier string
+		witness    map[string]interface{}
+		satisfied  bool
+	}{
+		{zkp.EqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, true},
+		{zkp.EqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 8}}, false},
+		{zkp.NotEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 8}}, true},
+		{zkp.NotEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, false},
+		{zkp.LessOrEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, true},
+		{zkp.LessOrEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 8, "RelVal": 7}}, false},
+		{zkp.LessProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 6, "RelVal": 7}}, true},
+		{zkp.LessProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, false},
+		{zkp.GreaterOrEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, true},
+		{zkp.GreaterOrEqualProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 6, "RelVal": 7}}, false},
+		{zkp.GreaterProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 8, "RelVal": 7}}, true},
+		{zkp.GreaterProver, map[string]interface{}{"Vals": map[string]interface{}{"Val": 7, "RelVal": 7}}, false},
+		{zkp.RangeProver, map[string]interface{}{"Value": 10, "Min": 10, "Max": 20}, true},
+		{zkp.RangeProver, map[string]interface{}{"Value": 21, "Min": 10, "Max": 20}, false},
+		{zkp.RangeProver, map[string]interface{}{"Value": 9, "Min": 10, "Max": 20}, false},
+	} {
+		provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+		r1cs, err := provider.Compile(provider.ProverFactory(tc.identifier))
+		if err != nil {
+			t.Fatalf("failed to compile %s prover; %s", tc.identifier, err.Error())
+		}
+
+		witval, err := provider.WitnessFactory(tc.identifier, "BN254", tc.witness, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build %s witness %v; %s", tc.identifier, tc.witness, err.Error())
+		}
+
+		err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+		if tc.satisfied && err != nil {
+			t.Fatalf("expected %s witness %v to be satisfied; %s", tc.identifier, tc.witness, err.Error())
+		} else if !tc.satisfied && err == nil {
+			t.Fatalf("expected %s witness %v to be unsatisfied", tc.identifier, tc.witness)
+		}
+	}
+}
+
+func TestRangeProverBitWidth(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+	variables := map[string]interface{}{"BitWidth": 8}
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.RangeProver), variables)
+	if err != nil {
+		t.Fatalf("failed to compile range prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup range prover; %s", err.Error())
+	}
+
+	witval, err := provider.WitnessFactory(zkp.RangeProver, "BN254", map[string]interface{}{
+		"Value": 200,
+		"Min":   0,
+		"Max":   255,
+	}, variables, false)
+	if err != nil {
+		t.Fatalf("failed to build range witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove range witness; %s", err.Error())
+	}
+
+	publicWitval, err := provider.WitnessFactory(zkp.RangeProver, "BN254", map[string]interface{}{
+		"Min": 0,
+		"Max": 255,
+	}, variables, true)
+	if err != nil {
+		t.Fatalf("failed to build range public witness; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), publicWitval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify range proof; %s", err.Error())
+	}
+
+	witval, err = provider.WitnessFactory(zkp.RangeProver, "BN254", map[string]interface{}{
+		"Value": 256,
+		"Min":   0,
+		"Max":   300,
+	}, variables, false)
+	if err != nil {
+		t.Fatalf("failed to build range witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err == nil {
+		t.Fatalf("expected range witness exceeding the 8-bit width to be unsatisfied")
+	}
+
+	_, err = zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16")).Compile(
+		provider.ProverFactory(zkp.LessProver),
+		map[string]interface{}{"BitWidth": 254},
+	)
+	if err == nil {
+		t.Fatalf("expected bit width exceeding the scalar field to fail compilation")
+	}
+}
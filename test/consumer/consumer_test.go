@@ -0,0 +1,55 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/provideplatform/privacy/prover"
+)
+
+func TestDrainNatsSubscriptions(t *testing.T) {
+	if !prover.NatsSubscriptionsReady() {
+		t.Error("consumer should be ready prior to draining")
+		return
+	}
+
+	startedAt := time.Now()
+	prover.DrainNatsSubscriptions(time.Second)
+	if time.Since(startedAt) >= time.Second {
+		t.Error("draining without in-flight messages should not wait for the drain timeout")
+		return
+	}
+
+	if prover.NatsSubscriptionsReady() {
+		t.Error("consumer should not be ready while draining")
+		return
+	}
+
+	// draining is idempotent
+	prover.DrainNatsSubscriptions(time.Second)
+
+	for _, status := range prover.NatsSubscriptionsStatus() {
+		if status.Valid > status.Total {
+			t.Errorf("invalid subscription status for subject: %s", status.Subject)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func TestProveAndVerifyContext(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err = provider.Setup(canceled, testutil.WriteTo(t, r1cs), nil)
+	if err != zkp.ErrCanceled {
+		t.Fatalf("expected setup with canceled context to fail with %s; got %v", zkp.ErrCanceled, err)
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(7).FillBytes(make([]byte, hFunc.BlockSize())))
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "7",
+		"Hash":     new(big.Int).SetBytes(hFunc.Sum(nil)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+	}
+
+	_, err = provider.Prove(canceled, testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != zkp.ErrCanceled {
+		t.Fatalf("expected proof with canceled context to fail with %s; got %v", zkp.ErrCanceled, err)
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelExpired()
+	<-expired.Done()
+
+	_, err = provider.Prove(expired, testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != zkp.ErrDeadlineExceeded {
+		t.Fatalf("expected proof exceeding deadline to fail with %s; got %v", zkp.ErrDeadlineExceeded, err)
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+	}
+
+	err = provider.Verify(canceled, testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+	if err != zkp.ErrCanceled {
+		t.Fatalf("expected verification with canceled context to fail with %s; got %v", zkp.ErrCanceled, err)
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify preimage hash proof; %s", err.Error())
+	}
+}
+
+func TestProveOperationsTracked(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, _, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(7).FillBytes(make([]byte, hFunc.BlockSize())))
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "7",
+		"Hash":     new(big.Int).SetBytes(hFunc.Sum(nil)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+	}
+
+	for _, timeout := range []time.Duration{time.Minute, time.Microsecond} {
+		wg := &sync.WaitGroup{}
+		ctx, cancel := context.WithTimeout(zkp.WithOperations(context.Background(), wg), timeout)
+
+		// an abandoned proof keeps running, and remains tracked, until it returns
+		_, err = provider.Prove(ctx, testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+		cancel()
+		if err != nil && err != zkp.ErrDeadlineExceeded {
+			t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Minute):
+			t.Fatalf("expected tracked proving operation to return")
+		}
+	}
+}
@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func documentHash(preimage *big.Int) *big.Int {
+	h := gnarkhash.MIMC_BN254.New()
+	h.Write(preimage.FillBytes(make([]byte, 32)))
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func TestDocumentProvers(t *testing.T) {
+	for i, identifier := range []string{
+		zkp.PurchaseOrderProver,
+		zkp.SalesOrderProver,
+		zkp.ShipmentNotificationProver,
+		zkp.GoodsReceiptProver,
+		zkp.InvoiceProver,
+	} {
+		provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+		circuit := provider.ProverFactory(identifier)
+		if circuit == nil {
+			t.Fatalf("failed to resolve %s prover", identifier)
+		}
+
+		r1cs, err := provider.Compile(circuit)
+		if err != nil {
+			t.Fatalf("failed to compile %s prover; %s", identifier, err.Error())
+		}
+
+		pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+		if err != nil {
+			t.Fatalf("failed to setup %s prover; %s", identifier, err.Error())
+		}
+
+		preimage := big.NewInt(int64(1000000 + i))
+		hash := documentHash(preimage)
+
+		witval, err := provider.WitnessFactory(identifier, "BN254", map[string]interface{}{
+			"Document": map[string]interface{}{
+				"Preimage": preimage.String(),
+				"Hash":     hash.String(),
+			},
+		}, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build %s witness; %s", identifier, err.Error())
+		}
+
+		proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove %s witness; %s", identifier, err.Error())
+		}
+
+		publicWitval, err := provider.WitnessFactory(identifier, "BN254", map[string]interface{}{
+			"Document": map[string]interface{}{
+				"Hash": hash.String(),
+			},
+		}, nil, true)
+		if err != nil {
+			t.Fatalf("failed to build %s public witness; %s", identifier, err.Error())
+		}
+
+		err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), publicWitval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify %s proof; %s", identifier, err.Error())
+		}
+
+		invalid, err := provider.WitnessFactory(identifier, "BN254", map[string]interface{}{
+			"Document": map[string]interface{}{
+				"Preimage": preimage.String(),
+				"Hash":     new(big.Int).Add(hash, big.NewInt(1)).String(),
+			},
+		}, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build invalid %s witness; %s", identifier, err.Error())
+		}
+
+		err = provider.Solve(testutil.WriteTo(t, r1cs), invalid)
+		if err == nil {
+			t.Fatalf("expected %s witness with mismatched document hash to be unsatisfied", identifier)
+		}
+	}
+}
@@ -20,7 +20,12 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"math/big"
 	"testing"
 	"time"
@@ -29,6 +34,10 @@ import (
 	gnarkhash "github.com/consensys/gnark-crypto/hash"
 
 	uuid "github.com/kthomas/go.uuid"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/store/providers/mmt"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
 	"github.com/provideplatform/provide-go/api/privacy"
 )
 
@@ -97,3 +106,255 @@ func TestPreimageHashProver(t *testing.T) {
 
 	t.Logf("generated zero-knowledge proof %s", *proof.Proof)
 }
+
+func TestVerifierContractDeploymentLifecycle(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	token, err := userTokenFactory(userID)
+	if err != nil {
+		t.Errorf("failed to initialize and authenticate user; %s", err.Error())
+		return
+	}
+
+	params := proverParamsFactory(
+		"BN254",
+		"General Consistency (Verifier Contract)",
+		"preimage_hash",
+		testProvingSchemeGroth16,
+		nil,
+		nil,
+	)
+	params["verifier_contract_deployer"] = "simulated"
+
+	prover, err := createProver(*token, params)
+	if err != nil {
+		t.Errorf("failed to create prover with verifier contract deployer; %s", err.Error())
+		return
+	}
+
+	statuses, details, err := awaitProverStatusTransitions(*token, prover.ID.String(), time.Millisecond*50)
+	if err != nil {
+		t.Errorf("failed to provision prover %s; %s", prover.ID, err.Error())
+		return
+	}
+	t.Logf("observed status transitions for prover %s: %v", prover.ID, statuses)
+
+	if statuses[len(statuses)-1] != "provisioned" {
+		t.Errorf("prover %s not provisioned; statuses: %v", prover.ID, statuses)
+		return
+	}
+
+	for i, status := range statuses {
+		if status == "deploying_artifacts" && i != len(statuses)-2 {
+			t.Errorf("prover %s transitioned out of deploying_artifacts to %s; statuses: %v", prover.ID, statuses[i+1], statuses)
+		}
+	}
+
+	if address, addressOk := details["verifier_contract_address"].(string); !addressOk || address == "" {
+		t.Errorf("provisioned prover %s has no verifier contract address", prover.ID)
+	}
+
+	if txHash, txHashOk := details["verifier_contract_tx_hash"].(string); !txHashOk || txHash == "" {
+		t.Errorf("provisioned prover %s has no verifier contract transaction hash", prover.ID)
+	}
+}
+
+func TestVerifyOnlyProver(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	token, err := userTokenFactory(userID)
+	if err != nil {
+		t.Errorf("failed to initialize and authenticate user; %s", err.Error())
+		return
+	}
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil(testProvingSchemeGroth16))
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Errorf("failed to compile preimage hash prover; %s", err.Error())
+		return
+	}
+
+	binary := new(bytes.Buffer)
+	r1cs.(io.WriterTo).WriteTo(binary)
+
+	pk, vk, err := provider.Setup(context.Background(), binary.Bytes(), nil)
+	if err != nil {
+		t.Errorf("failed to setup preimage hash prover; %s", err.Error())
+		return
+	}
+
+	provingKey := new(bytes.Buffer)
+	pk.(io.WriterTo).WriteTo(provingKey)
+	verifyingKey := new(bytes.Buffer)
+	vk.(io.WriterTo).WriteTo(verifyingKey)
+
+	preimage := big.NewInt(1234567890)
+	hash := gnarkhash.MIMC_BN254.New()
+	hash.Write(preimage.FillBytes(make([]byte, 32)))
+	witness := map[string]interface{}{
+		"Preimage": preimage.String(),
+		"Hash":     new(big.Int).SetBytes(hash.Sum(nil)).String(),
+	}
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", witness, nil, false)
+	if err != nil {
+		t.Errorf("failed to build witness; %s", err.Error())
+		return
+	}
+
+	proof, err := provider.Prove(context.Background(), binary.Bytes(), provingKey.Bytes(), witval, nil)
+	if err != nil {
+		t.Errorf("failed to generate proof; %s", err.Error())
+		return
+	}
+
+	proofBuf := new(bytes.Buffer)
+	proof.(io.WriterTo).WriteTo(proofBuf)
+
+	params := proverParamsFactory("BN254", "General Consistency (Verify-Only)", "preimage_hash", testProvingSchemeGroth16, nil, nil)
+	params["verify_only"] = true
+	params["artifacts"] = map[string]interface{}{
+		"verifying_key": hex.EncodeToString(verifyingKey.Bytes()),
+	}
+
+	prover, err := createProver(*token, params)
+	if err != nil {
+		t.Errorf("failed to import verify-only prover; %s", err.Error())
+		return
+	}
+
+	if prover.Status == nil || *prover.Status != "provisioned" {
+		t.Errorf("imported verify-only prover %s not provisioned", prover.ID)
+		return
+	}
+
+	_, err = privacy.Prove(*token, prover.ID.String(), map[string]interface{}{
+		"witness": witness,
+	})
+	if err == nil {
+		t.Errorf("verify-only prover %s generated a proof", prover.ID)
+	}
+
+	verification, err := privacy.Verify(*token, prover.ID.String(), map[string]interface{}{
+		"proof":   hex.EncodeToString(proofBuf.Bytes()),
+		"witness": map[string]interface{}{"Hash": witness["Hash"]},
+	})
+	if err != nil {
+		t.Errorf("failed to verify proof using verify-only prover %s; %s", prover.ID, err.Error())
+		return
+	}
+
+	if !verification.Result {
+		t.Errorf("valid proof not verified by verify-only prover %s", prover.ID)
+	}
+}
+
+func TestCompileVariablesRoundTrip(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	token, err := userTokenFactory(userID)
+	if err != nil {
+		t.Errorf("failed to initialize and authenticate user; %s", err.Error())
+		return
+	}
+
+	const batchSize = 2
+
+	params := proverParamsFactory("BN254", "Rollup (Compile Variables)", zkp.BaselineRollupProver, testProvingSchemeGroth16, nil, nil)
+	params["variables"] = map[string]interface{}{
+		"Leaves_count": fmt.Sprintf("%d", batchSize),
+		"Paths_count":  fmt.Sprintf("%d", batchSize),
+	}
+
+	prover, err := createProver(*token, params)
+	if err != nil {
+		t.Errorf("failed to create prover with compile-time variables; %s", err.Error())
+		return
+	}
+
+	statuses, details, err := awaitProverStatusTransitions(*token, prover.ID.String(), requireProverSleepInterval)
+	if err != nil || statuses[len(statuses)-1] != "provisioned" {
+		t.Errorf("failed to provision prover %s; statuses: %v; %v", prover.ID, statuses, err)
+		return
+	}
+
+	variables, variablesOk := details["variables"].(map[string]interface{})
+	if !variablesOk || variables["Leaves_count"] != fmt.Sprintf("%d", batchSize) || variables["Paths_count"] != fmt.Sprintf("%d", batchSize) {
+		t.Errorf("compile-time variables not persisted for prover %s; %v", prover.ID, details["variables"])
+		return
+	}
+
+	tree := mmt.NewTree(gnarkhash.MIMC_BN254.New(), gnark.NoteTreeDepth)
+	tree.Add([]byte(`{"note": 0}`))
+
+	// the witness carries no slice counts; they are re-applied from the persisted variables
+	for _, tc := range []struct {
+		batch  int
+		solved bool
+	}{
+		{batchSize, true},
+		{batchSize + 1, false},
+	} {
+		batch := make([][]byte, tc.batch)
+		for i := range batch {
+			batch[i] = []byte(fmt.Sprintf(`{"note": %d}`, i+1))
+		}
+
+		proof, err := tree.Rollup(batch, tc.batch)
+		if err != nil {
+			t.Errorf("failed to resolve rollup proof; %s", err.Error())
+			return
+		}
+
+		status, resp, err := privacy.InitPrivacyService(*token).Post(fmt.Sprintf("provers/%s/solve", prover.ID), map[string]interface{}{
+			"witness": proof.Witness(),
+		})
+		if err != nil {
+			t.Errorf("failed to solve witness for prover %s; %s", prover.ID, err.Error())
+			return
+		}
+
+		solved, _ := resp.(map[string]interface{})["solved"].(bool)
+		if solved != tc.solved {
+			t.Errorf("expected solved to be %v for batch of %d leaves using prover %s; status: %d; %v", tc.solved, tc.batch, prover.ID, status, resp)
+		}
+	}
+}
+
+func TestProcureToPayWorkflow(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	token, err := userTokenFactory(userID)
+	if err != nil {
+		t.Errorf("failed to initialize and authenticate user; %s", err.Error())
+		return
+	}
+
+	provers, err := createProcureToPayWorkflow(token, testProvingSchemeGroth16)
+	if err != nil {
+		t.Errorf("failed to initialize procure-to-pay workflow provers; %s", err.Error())
+		return
+	}
+
+	err = requireProvers(token, provers)
+	if err != nil {
+		t.Errorf("failed to provision procure-to-pay workflow provers; %s", err.Error())
+		return
+	}
+
+	// preimages are MiMC BN254 digests of each document payload
+	hFunc := gnarkhash.MIMC_BN254.New()
+
+	var prevProver *privacy.Prover
+	for i, prover := range provers {
+		payload := map[string]interface{}{
+			"document": *prover.Identifier,
+			"value":    11111111 * (i + 1),
+		}
+
+		_, err := testDocumentProverLifecycle(t, hFunc, token, uint64(i), prover, prevProver, payload)
+		if err != nil {
+			t.Errorf("failed %s prover lifecycle; %s", *prover.Identifier, err.Error())
+			return
+		}
+
+		prevProver = prover
+	}
+}
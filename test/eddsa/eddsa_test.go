@@ -0,0 +1,212 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func generateKey(t *testing.T) *eddsa.PrivateKey {
+	sk, err := eddsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate eddsa key; %s", err.Error())
+	}
+	return sk
+}
+
+func sign(t *testing.T, sk *eddsa.PrivateKey, msg []byte) []byte {
+	sig, err := sk.Sign(msg, gnarkhash.MIMC_BN254.New())
+	if err != nil {
+		t.Fatalf("failed to sign message; %s", err.Error())
+	}
+	return sig
+}
+
+func mimcHash(elements ...[]byte) []byte {
+	h := gnarkhash.MIMC_BN254.New()
+	for _, e := range elements {
+		h.Write(e)
+	}
+	return h.Sum(nil)
+}
+
+func prove(t *testing.T, identifier string, witness, publicWitness map[string]interface{}) error {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(identifier))
+	if err != nil {
+		t.Fatalf("failed to compile %s prover; %s", identifier, err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup %s prover; %s", identifier, err.Error())
+	}
+
+	witval, err := provider.WitnessFactory(identifier, "BN254", witness, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build %s witness; %s", identifier, err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err != nil {
+		return err
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove %s witness; %s", identifier, err.Error())
+	}
+
+	publicWitval, err := provider.WitnessFactory(identifier, "BN254", publicWitness, nil, true)
+	if err != nil {
+		t.Fatalf("failed to build %s public witness; %s", identifier, err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), publicWitval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify %s proof; %s", identifier, err.Error())
+	}
+
+	return nil
+}
+
+func TestOwnershipSk(t *testing.T) {
+	sk := generateKey(t)
+	pk := hex.EncodeToString(sk.Public().Bytes())
+
+	err := prove(t, zkp.OwnershipSkProver, map[string]interface{}{
+		"Pk": pk,
+		"Sk": hex.EncodeToString(sk.Bytes()),
+	}, map[string]interface{}{
+		"Pk": pk,
+	})
+	if err != nil {
+		t.Fatalf("failed to prove secret key ownership; %s", err.Error())
+	}
+
+	err = prove(t, zkp.OwnershipSkProver, map[string]interface{}{
+		"Pk": pk,
+		"Sk": hex.EncodeToString(generateKey(t).Bytes()),
+	}, map[string]interface{}{
+		"Pk": pk,
+	})
+	if err == nil {
+		t.Fatalf("expected secret key ownership of another party's public key to be unsatisfied")
+	}
+}
+
+func TestBaselineDocumentComplete(t *testing.T) {
+	sk := generateKey(t)
+	pk := hex.EncodeToString(sk.Public().Bytes())
+
+	preimage := mimcHash([]byte(`{"hello":"world"}`))
+	hash := mimcHash(preimage)
+	sig := hex.EncodeToString(sign(t, sk, hash))
+
+	public := map[string]interface{}{
+		"Doc": map[string]interface{}{
+			"Hash": new(big.Int).SetBytes(hash).String(),
+		},
+		"Pk":  pk,
+		"Sig": sig,
+	}
+
+	err := prove(t, zkp.BaselineDocumentCompleteProver, map[string]interface{}{
+		"Doc": map[string]interface{}{
+			"Preimage": new(big.Int).SetBytes(preimage).String(),
+			"Hash":     new(big.Int).SetBytes(hash).String(),
+		},
+		"Pk":  pk,
+		"Sk":  hex.EncodeToString(sk.Bytes()),
+		"Sig": sig,
+	}, public)
+	if err != nil {
+		t.Fatalf("failed to prove signed document; %s", err.Error())
+	}
+
+	err = prove(t, zkp.BaselineDocumentCompleteProver, map[string]interface{}{
+		"Doc": map[string]interface{}{
+			"Preimage": new(big.Int).SetBytes(preimage).String(),
+			"Hash":     new(big.Int).SetBytes(hash).String(),
+		},
+		"Pk":  pk,
+		"Sk":  hex.EncodeToString(sk.Bytes()),
+		"Sig": hex.EncodeToString(sign(t, sk, mimcHash(hash))),
+	}, public)
+	if err == nil {
+		t.Fatalf("expected document signed over another hash to be unsatisfied")
+	}
+}
+
+func TestProofEddsa(t *testing.T) {
+	sk := generateKey(t)
+	pk := hex.EncodeToString(sk.Public().Bytes())
+
+	msg := make([]interface{}, 32)
+	elements := make([][]byte, 32)
+	for i := range msg {
+		var e fr.Element
+		e.SetUint64(uint64(i * 31))
+		b := e.Bytes()
+		elements[i] = b[:]
+		msg[i] = e.String()
+	}
+
+	sig := hex.EncodeToString(sign(t, sk, mimcHash(elements...)))
+
+	err := prove(t, zkp.ProofEddsaProver, map[string]interface{}{
+		"Msg":    msg,
+		"PubKey": pk,
+		"Sig":    sig,
+	}, map[string]interface{}{
+		"PubKey": pk,
+		"Sig":    sig,
+	})
+	if err != nil {
+		t.Fatalf("failed to prove eddsa signature; %s", err.Error())
+	}
+}
+
+func TestEncodedWitnessErrors(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	for _, witness := range []map[string]interface{}{
+		{"Pk": "not-hex"},
+		{"Pk": "0x01"},
+		{"Sk": "0x01"},
+	} {
+		_, err := provider.WitnessFactory(zkp.OwnershipSkProver, "BN254", witness, nil, false)
+		if err == nil {
+			t.Fatalf("expected error for witness %v", witness)
+		}
+	}
+}
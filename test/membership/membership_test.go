@@ -0,0 +1,177 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/store/providers/mmt"
+	"github.com/provideplatform/privacy/test/testutil"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func noteTree(t *testing.T, size int) *mmt.Tree {
+	tree := mmt.NewTree(gnarkhash.MIMC_BN254.New(), gnark.NoteTreeDepth)
+	for i := 0; i < size; i++ {
+		_, err := tree.Add([]byte(fmt.Sprintf(`{"note": %d}`, i)))
+		if err != nil {
+			t.Fatalf("failed to add note %d; %s", i, err.Error())
+		}
+	}
+	return tree
+}
+
+func TestNoteTree(t *testing.T) {
+	empty := noteTree(t, 0)
+	if len(empty.Root()) != 32 {
+		t.Fatalf("expected 32-byte root for empty tree; got %d bytes", len(empty.Root()))
+	}
+
+	_, err := empty.MembershipProof(0)
+	if err == nil {
+		t.Fatalf("expected membership proof for empty tree to fail")
+	}
+
+	tree := noteTree(t, 5)
+	if bytes.Equal(tree.Root(), empty.Root()) {
+		t.Fatalf("expected root to change after adding notes")
+	}
+
+	if !tree.Contains([]byte(`{"note": 3}`)) || tree.Contains([]byte(`{"note": 5}`)) {
+		t.Fatalf("unexpected tree membership")
+	}
+
+	for i := uint64(0); i < 5; i++ {
+		proof, err := tree.MembershipProof(i)
+		if err != nil {
+			t.Fatalf("failed to resolve membership proof at index %d; %s", i, err.Error())
+		}
+
+		if len(proof.Path) != gnark.NoteTreeDepth {
+			t.Fatalf("expected %d-element path; got %d", gnark.NoteTreeDepth, len(proof.Path))
+		}
+
+		if !tree.Verify(tree.Root(), proof) {
+			t.Fatalf("failed to verify membership proof at index %d", i)
+		}
+
+		if tree.Verify(empty.Root(), proof) {
+			t.Fatalf("expected membership proof at index %d to fail verification against another root", i)
+		}
+	}
+}
+
+func TestNoteTreeAddAll(t *testing.T) {
+	vals := make([][]byte, 0)
+	for i := 0; i < 7; i++ {
+		vals = append(vals, []byte(fmt.Sprintf(`{"note": %d}`, i)))
+	}
+
+	// each value added incrementally updates only the path of its leaf to the root
+	tree := noteTree(t, len(vals))
+
+	loaded := mmt.NewTree(gnarkhash.MIMC_BN254.New(), gnark.NoteTreeDepth)
+	err := loaded.AddAll(vals)
+	if err != nil {
+		t.Fatalf("failed to add values; %s", err.Error())
+	}
+
+	if !bytes.Equal(tree.Root(), loaded.Root()) {
+		t.Fatalf("expected roots of incrementally added and loaded trees to match")
+	}
+
+	for i := uint64(0); i < uint64(len(vals)); i++ {
+		proof, err := tree.MembershipProof(i)
+		if err != nil {
+			t.Fatalf("failed to resolve membership proof at index %d; %s", i, err.Error())
+		}
+
+		if !loaded.Verify(loaded.Root(), proof) {
+			t.Fatalf("failed to verify membership proof at index %d against loaded tree", i)
+		}
+	}
+}
+
+func TestNoteMembershipProver(t *testing.T) {
+	tree := noteTree(t, 7)
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.NoteMembershipProver))
+	if err != nil {
+		t.Fatalf("failed to compile note membership prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup note membership prover; %s", err.Error())
+	}
+
+	for _, index := range []uint64{0, 3, 6} {
+		proof, err := tree.MembershipProof(index)
+		if err != nil {
+			t.Fatalf("failed to resolve membership proof at index %d; %s", index, err.Error())
+		}
+
+		witval, err := provider.WitnessFactory(zkp.NoteMembershipProver, "BN254", proof.Witness(), nil, false)
+		if err != nil {
+			t.Fatalf("failed to build note membership witness; %s", err.Error())
+		}
+
+		zkproof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove note membership at index %d; %s", index, err.Error())
+		}
+
+		publicWitval, err := provider.WitnessFactory(zkp.NoteMembershipProver, "BN254", map[string]interface{}{
+			"Root":       new(big.Int).SetBytes(tree.Root()).String(),
+			"Commitment": new(big.Int).SetBytes(proof.Commitment).String(),
+		}, nil, true)
+		if err != nil {
+			t.Fatalf("failed to build note membership public witness; %s", err.Error())
+		}
+
+		err = provider.Verify(context.Background(), testutil.WriteTo(t, zkproof), testutil.WriteTo(t, vk), publicWitval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify note membership proof at index %d; %s", index, err.Error())
+		}
+	}
+
+	proof, _ := tree.MembershipProof(2)
+	witness := proof.Witness()
+	witness["Index"] = 3
+
+	witval, err := provider.WitnessFactory(zkp.NoteMembershipProver, "BN254", witness, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build note membership witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err == nil {
+		t.Fatalf("expected note membership witness with mismatched index to be unsatisfied")
+	}
+}
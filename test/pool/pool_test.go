@@ -0,0 +1,222 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/provideplatform/privacy/common"
+)
+
+func TestWorkerPoolQueueFull(t *testing.T) {
+	pool := common.NewWorkerPool(1, 1, 0, 0, time.Second)
+
+	release, err := pool.Acquire(context.Background(), "a", 0)
+	if err != nil {
+		t.Fatalf("failed to acquire worker; %s", err.Error())
+	}
+
+	queued := make(chan error)
+	go func() {
+		release, err := pool.Acquire(context.Background(), "b", 0)
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+
+	waitForQueued(t, pool, 1)
+
+	_, err = pool.Acquire(context.Background(), "c", 0)
+	if err != common.ErrWorkerPoolQueueFull {
+		t.Fatalf("expected full queue error; got %v", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Fatalf("expected queued job to acquire worker; %s", err.Error())
+	}
+
+	status := pool.Status()
+	if status.Running != 0 || status.Queued != 0 {
+		t.Fatalf("expected idle pool; %d running, %d queued", status.Running, status.Queued)
+	}
+}
+
+func TestWorkerPoolKeyLimit(t *testing.T) {
+	pool := common.NewWorkerPool(4, 4, 2, 0, time.Second)
+
+	releaseA, _ := pool.Acquire(context.Background(), "prover", 0)
+	releaseB, _ := pool.Acquire(context.Background(), "prover", 0)
+
+	_, err := pool.Acquire(context.Background(), "prover", 0)
+	if err != common.ErrWorkerPoolKeyLimitExceeded {
+		t.Fatalf("expected key limit error; got %v", err)
+	}
+
+	release, err := pool.Acquire(context.Background(), "other", 0)
+	if err != nil {
+		t.Fatalf("expected job for another key to acquire worker; %s", err.Error())
+	}
+	release()
+
+	releaseA()
+	releaseA() // releasing twice is a no-op
+
+	release, err = pool.Acquire(context.Background(), "prover", 0)
+	if err != nil {
+		t.Fatalf("expected job to acquire worker after release; %s", err.Error())
+	}
+	release()
+	releaseB()
+}
+
+func TestWorkerPoolMemoryLimit(t *testing.T) {
+	pool := common.NewWorkerPool(4, 4, 0, 100, 50*time.Millisecond)
+
+	// a job exceeding the memory limit is admitted when the pool is idle
+	release, err := pool.Acquire(context.Background(), "a", 150)
+	if err != nil {
+		t.Fatalf("expected job to acquire idle pool; %s", err.Error())
+	}
+
+	_, err = pool.Acquire(context.Background(), "b", 10)
+	if err != common.ErrWorkerPoolQueueTimeout {
+		t.Fatalf("expected queue timeout while memory limit exceeded; got %v", err)
+	}
+	release()
+
+	releaseA, _ := pool.Acquire(context.Background(), "a", 60)
+	releaseB, err := pool.Acquire(context.Background(), "b", 40)
+	if err != nil {
+		t.Fatalf("expected job within memory limit to acquire worker; %s", err.Error())
+	}
+
+	if status := pool.Status(); status.Memory != 100 || status.Running != 2 {
+		t.Fatalf("expected 2 running jobs using 100 bytes; got %d using %d bytes", status.Running, status.Memory)
+	}
+
+	releaseA()
+	releaseB()
+
+	if pool.RetryAfter() < time.Second {
+		t.Fatalf("expected retry hint of at least one second")
+	}
+}
+
+func TestWorkerPoolAcquireCanceled(t *testing.T) {
+	pool := common.NewWorkerPool(1, 1, 1, 0, time.Minute)
+
+	release, err := pool.Acquire(context.Background(), "a", 0)
+	if err != nil {
+		t.Fatalf("failed to acquire worker; %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error)
+	go func() {
+		_, err := pool.Acquire(ctx, "b", 0)
+		queued <- err
+	}()
+
+	waitForQueued(t, pool, 1)
+	cancel()
+
+	// the queued job gives up when its context is canceled, well before the queue timeout
+	select {
+	case err := <-queued:
+		if err != context.Canceled {
+			t.Fatalf("expected canceled error; got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected queued job to give up when its context is canceled")
+	}
+
+	if status := pool.Status(); status.Queued != 0 || status.Running != 1 {
+		t.Fatalf("expected canceled job to leave the queue; %d running, %d queued", status.Running, status.Queued)
+	}
+
+	// the admission of the canceled job for its key is released
+	release()
+	release, err = pool.Acquire(context.Background(), "b", 0)
+	if err != nil {
+		t.Fatalf("expected admission of canceled job to be released; %s", err.Error())
+	}
+	release()
+}
+
+func TestWorkerPoolQueueOrder(t *testing.T) {
+	pool := common.NewWorkerPool(2, 2, 0, 100, time.Minute)
+
+	releaseA, err := pool.Acquire(context.Background(), "a", 60)
+	if err != nil {
+		t.Fatalf("failed to acquire worker; %s", err.Error())
+	}
+
+	started := make(chan string, 2)
+	releases := make(chan func(), 2)
+	acquire := func(key string, cost int64) {
+		release, err := pool.Acquire(context.Background(), key, cost)
+		if err != nil {
+			t.Errorf("failed to acquire worker for %s; %s", key, err.Error())
+			return
+		}
+		started <- key
+		releases <- release
+	}
+
+	// a large job is queued, and a smaller job which would fit is queued behind it
+	go acquire("b", 100)
+	waitForQueued(t, pool, 1)
+	go acquire("c", 20)
+	waitForQueued(t, pool, 2)
+
+	select {
+	case key := <-started:
+		t.Fatalf("expected no job to start ahead of the queued jobs; %s started", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseA()
+	if key := <-started; key != "b" {
+		t.Fatalf("expected queued jobs to start in order; %s started first", key)
+	}
+
+	(<-releases)()
+	if key := <-started; key != "c" {
+		t.Fatalf("expected queued jobs to start in order; %s started", key)
+	}
+	(<-releases)()
+
+	if status := pool.Status(); status.Running != 0 || status.Queued != 0 || status.Memory != 0 {
+		t.Fatalf("expected idle pool; %d running, %d queued using %d bytes", status.Running, status.Queued, status.Memory)
+	}
+}
+
+func waitForQueued(t *testing.T, pool *common.WorkerPool, queued int) {
+	for i := 0; i < 100; i++ {
+		if pool.Status().Queued == queued {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d queued job(s)", queued)
+}
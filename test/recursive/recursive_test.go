@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func mimcHash(h gnarkhash.Hash, preimage []byte) []byte {
+	hFunc := h.New()
+	hFunc.Write(preimage)
+	return hFunc.Sum(nil)
+}
+
+func compile(t *testing.T, provider *zkp.GnarkProverProvider, identifier string, variables map[string]interface{}) []byte {
+	r1cs, err := provider.Compile(provider.ProverFactory(identifier), variables)
+	if err != nil {
+		t.Fatalf("failed to compile %s prover; %s", identifier, err.Error())
+	}
+	return testutil.WriteTo(t, r1cs)
+}
+
+func TestComposeRecursiveProof(t *testing.T) {
+	innerProvider := zkp.InitGnarkProverProvider(common.StringOrNil("BLS12_377"), common.StringOrNil("groth16"))
+	innerR1CS := compile(t, innerProvider, zkp.PreimageHashProver, nil)
+
+	pk, vk, err := innerProvider.Setup(context.Background(), innerR1CS, nil)
+	if err != nil {
+		t.Fatalf("failed to setup inner prover; %s", err.Error())
+	}
+	innerPK := testutil.WriteTo(t, pk)
+	innerVK := testutil.WriteTo(t, vk)
+
+	innerPreimage := mimcHash(gnarkhash.MIMC_BLS12_377, []byte("inner"))
+	innerWitval, err := innerProvider.WitnessFactory(zkp.PreimageHashProver, "BLS12_377", map[string]interface{}{
+		"Preimage": new(big.Int).SetBytes(innerPreimage).String(),
+		"Hash":     new(big.Int).SetBytes(mimcHash(gnarkhash.MIMC_BLS12_377, innerPreimage)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build inner witness; %s", err.Error())
+	}
+
+	innerProof, err := innerProvider.Prove(context.Background(), innerR1CS, innerPK, innerWitval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove inner witness; %s", err.Error())
+	}
+
+	innerPublicWitness, err := innerProvider.PublicWitness(innerWitval)
+	if err != nil {
+		t.Fatalf("failed to resolve inner public witness; %s", err.Error())
+	}
+
+	// the inner proof is verified against the hex-encoded public witness recorded with the proof
+	storedWitval, err := innerProvider.WitnessFactory(zkp.PreimageHashProver, "BLS12_377", hex.EncodeToString(innerPublicWitness), nil, true)
+	if err != nil {
+		t.Fatalf("failed to read recorded inner public witness; %s", err.Error())
+	}
+
+	err = innerProvider.Verify(context.Background(), testutil.WriteTo(t, innerProof), innerVK, storedWitval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify inner proof against recorded public witness; %s", err.Error())
+	}
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BW6_761"), common.StringOrNil("groth16"))
+	variables := map[string]interface{}{
+		"VerifyingKey.G1.K_count": 2,
+	}
+	// the composed witness is solved rather than proven as setup of the outer circuit is expensive
+	r1cs := compile(t, provider, zkp.RecursiveProofProver, variables)
+
+	preimage := mimcHash(gnarkhash.MIMC_BW6_761, []byte("outer"))
+	preimageHash := new(big.Int).SetBytes(mimcHash(gnarkhash.MIMC_BW6_761, preimage)).String()
+
+	witval, err := provider.ComposeWitness(zkp.RecursiveProofProver, "BW6_761", map[string]interface{}{
+		"Preimage":     new(big.Int).SetBytes(preimage).String(),
+		"PreimageHash": preimageHash,
+	}, variables, testutil.WriteTo(t, innerProof), innerVK, innerPublicWitness)
+	if err != nil {
+		t.Fatalf("failed to compose recursive witness; %s", err.Error())
+	}
+
+	err = provider.Solve(r1cs, witval)
+	if err != nil {
+		t.Fatalf("failed to solve recursive witness; %s", err.Error())
+	}
+
+	// the outer witness is unsatisfied when the inner proof is verified against other public inputs
+	otherPreimage := mimcHash(gnarkhash.MIMC_BLS12_377, []byte("other"))
+	otherWitval, err := innerProvider.WitnessFactory(zkp.PreimageHashProver, "BLS12_377", map[string]interface{}{
+		"Preimage": new(big.Int).SetBytes(otherPreimage).String(),
+		"Hash":     new(big.Int).SetBytes(mimcHash(gnarkhash.MIMC_BLS12_377, otherPreimage)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build inner witness; %s", err.Error())
+	}
+
+	otherPublicWitness, err := innerProvider.PublicWitness(otherWitval)
+	if err != nil {
+		t.Fatalf("failed to resolve inner public witness; %s", err.Error())
+	}
+
+	witval, err = provider.ComposeWitness(zkp.RecursiveProofProver, "BW6_761", map[string]interface{}{
+		"Preimage":     new(big.Int).SetBytes(preimage).String(),
+		"PreimageHash": preimageHash,
+	}, variables, testutil.WriteTo(t, innerProof), innerVK, otherPublicWitness)
+	if err != nil {
+		t.Fatalf("failed to compose recursive witness; %s", err.Error())
+	}
+
+	err = provider.Solve(r1cs, witval)
+	if err == nil {
+		t.Fatalf("expected recursive witness for inner proof of other public inputs to be unsatisfied")
+	}
+}
+
+func TestComposeRecursiveProofInvalidArtifacts(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BW6_761"), common.StringOrNil("groth16"))
+
+	_, err := provider.ComposeWitness(zkp.PreimageHashProver, "BW6_761", map[string]interface{}{}, nil, nil, nil, nil)
+	if err == nil {
+		t.Fatalf("expected composition using non-recursive prover to fail")
+	}
+
+	_, err = provider.ComposeWitness(zkp.RecursiveProofProver, "BW6_761", map[string]interface{}{}, nil, []byte{0x01}, []byte{0x01}, []byte{0, 0, 0, 1, 0x01})
+	if err == nil {
+		t.Fatalf("expected composition using invalid inner artifacts to fail")
+	}
+}
@@ -0,0 +1,209 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/store/providers/mmt"
+	"github.com/provideplatform/privacy/test/testutil"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+const rollupBatchSize = 4
+
+func notes(from, to int) [][]byte {
+	vals := make([][]byte, 0)
+	for i := from; i < to; i++ {
+		vals = append(vals, []byte(fmt.Sprintf(`{"note": %d}`, i)))
+	}
+	return vals
+}
+
+func noteTree(t *testing.T, vals [][]byte) *mmt.Tree {
+	tree := mmt.NewTree(gnarkhash.MIMC_BN254.New(), gnark.NoteTreeDepth)
+	for _, val := range vals {
+		_, err := tree.Add(val)
+		if err != nil {
+			t.Fatalf("failed to add note; %s", err.Error())
+		}
+	}
+	return tree
+}
+
+func TestNoteTreeRollup(t *testing.T) {
+	tree := noteTree(t, notes(0, 5))
+	root := tree.Root()
+
+	proof, err := tree.Rollup(notes(5, 8), rollupBatchSize)
+	if err != nil {
+		t.Fatalf("failed to resolve rollup proof; %s", err.Error())
+	}
+
+	if !bytes.Equal(tree.Root(), root) || tree.Size() != 5 {
+		t.Fatalf("expected rollup proof not to modify the tree")
+	}
+
+	if proof.Index != 5 || len(proof.Leaves) != rollupBatchSize || len(proof.Paths) != rollupBatchSize {
+		t.Fatalf("unexpected rollup proof; index: %d; %d leaves; %d paths", proof.Index, len(proof.Leaves), len(proof.Paths))
+	}
+
+	if !bytes.Equal(proof.OldRoot, root) || !bytes.Equal(proof.NewRoot, noteTree(t, notes(0, 8)).Root()) {
+		t.Fatalf("expected rollup proof to transition the root to that of the tree containing the batch")
+	}
+
+	_, err = tree.Rollup(notes(5, 10), rollupBatchSize)
+	if err == nil {
+		t.Fatalf("expected rollup of more notes than the batch size to fail")
+	}
+
+	_, err = tree.Rollup(nil, rollupBatchSize)
+	if err == nil {
+		t.Fatalf("expected rollup of no notes to fail")
+	}
+}
+
+func TestNoteTreeRollupOfInsertedBatch(t *testing.T) {
+	tree := noteTree(t, notes(0, 5))
+
+	proof, err := tree.Rollup(notes(5, 8), rollupBatchSize)
+	if err != nil {
+		t.Fatalf("failed to resolve rollup proof; %s", err.Error())
+	}
+
+	if tree.Inserted(notes(5, 8)) {
+		t.Fatalf("expected batch not to be inserted")
+	}
+
+	for _, val := range notes(5, 8) {
+		_, err := tree.Add(val)
+		if err != nil {
+			t.Fatalf("failed to add note; %s", err.Error())
+		}
+	}
+
+	// a batch inserted by an incomplete rollup is recognized by the tail of the tree
+	if !tree.Inserted(notes(5, 8)) || !tree.Inserted(notes(7, 8)) {
+		t.Fatalf("expected batch to be inserted")
+	}
+
+	if tree.Inserted(notes(4, 7)) || tree.Inserted(notes(6, 9)) || tree.Inserted(nil) {
+		t.Fatalf("expected only the last notes of the tree to be inserted")
+	}
+
+	_proof, err := tree.RollupAt(5, notes(5, 8), rollupBatchSize)
+	if err != nil {
+		t.Fatalf("failed to resolve rollup proof of inserted batch; %s", err.Error())
+	}
+
+	if !bytes.Equal(_proof.OldRoot, proof.OldRoot) || !bytes.Equal(_proof.NewRoot, proof.NewRoot) || _proof.Index != proof.Index {
+		t.Fatalf("expected rollup proof of inserted batch to match the proof of its insertion")
+	}
+
+	for i := range proof.Paths {
+		if !bytes.Equal(_proof.Leaves[i], proof.Leaves[i]) {
+			t.Fatalf("expected rollup proof of inserted batch to contain leaf %d of the proof of its insertion", i)
+		}
+
+		for j := range proof.Paths[i] {
+			if !bytes.Equal(_proof.Paths[i][j], proof.Paths[i][j]) {
+				t.Fatalf("expected rollup proof of inserted batch to contain path %d of the proof of its insertion", i)
+			}
+		}
+	}
+
+	_, err = tree.RollupAt(9, notes(9, 10), rollupBatchSize)
+	if err == nil {
+		t.Fatalf("expected rollup at an index beyond the size of the tree to fail")
+	}
+}
+
+func TestBaselineRollupProver(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+	variables := map[string]interface{}{
+		"Leaves_count": rollupBatchSize,
+		"Paths_count":  rollupBatchSize,
+	}
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.BaselineRollupProver), variables)
+	if err != nil {
+		t.Fatalf("failed to compile rollup prover; %s", err.Error())
+	}
+
+	for _, tc := range []struct {
+		size  int
+		batch int
+	}{
+		{0, 4}, // full batch into an empty tree
+		{5, 3}, // partial batch
+		{6, 1},
+	} {
+		tree := noteTree(t, notes(0, tc.size))
+		proof, err := tree.Rollup(notes(tc.size, tc.size+tc.batch), rollupBatchSize)
+		if err != nil {
+			t.Fatalf("failed to resolve rollup proof; %s", err.Error())
+		}
+
+		witval, err := provider.WitnessFactory(zkp.BaselineRollupProver, "BN254", proof.Witness(), variables, false)
+		if err != nil {
+			t.Fatalf("failed to build rollup witness; %s", err.Error())
+		}
+
+		err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+		if err != nil {
+			t.Fatalf("failed to solve rollup of %d note(s) into tree of size %d; %s", tc.batch, tc.size, err.Error())
+		}
+	}
+
+	tree := noteTree(t, notes(0, 5))
+	proof, _ := tree.Rollup(notes(5, 8), rollupBatchSize)
+
+	// the batch must be inserted at the next index
+	witness := proof.Witness()
+	witness["Index"] = 4
+
+	witval, err := provider.WitnessFactory(zkp.BaselineRollupProver, "BN254", witness, variables, false)
+	if err != nil {
+		t.Fatalf("failed to build rollup witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err == nil {
+		t.Fatalf("expected rollup witness overwriting an existing note to be unsatisfied")
+	}
+
+	// the new root must be that of the tree containing the batch
+	witness = proof.Witness()
+	witness["NewRoot"] = witness["OldRoot"]
+
+	witval, err = provider.WitnessFactory(zkp.BaselineRollupProver, "BN254", witness, variables, false)
+	if err != nil {
+		t.Fatalf("failed to build rollup witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err == nil {
+		t.Fatalf("expected rollup witness with unchanged root to be unsatisfied")
+	}
+}
@@ -0,0 +1,92 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"testing"
+
+	"github.com/provideplatform/privacy/common"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func fieldVisibility(schema map[string]interface{}) map[string]string {
+	visibility := map[string]string{}
+	for _, field := range schema["fields"].([]map[string]interface{}) {
+		visibility[field["path"].(string)] = field["visibility"].(string)
+	}
+	return visibility
+}
+
+func TestPreimageHashWitnessSchema(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	schema, err := provider.WitnessSchema(zkp.PreimageHashProver, nil)
+	if err != nil {
+		t.Fatalf("failed to resolve witness schema; %s", err.Error())
+	}
+
+	visibility := fieldVisibility(schema)
+	if visibility["Preimage"] != "secret" {
+		t.Errorf("expected Preimage to be secret; got %s", visibility["Preimage"])
+	}
+	if visibility["Hash"] != "public" {
+		t.Errorf("expected Hash to be public; got %s", visibility["Hash"])
+	}
+	if len(schema["counts"].([]string)) != 0 {
+		t.Errorf("expected no count variables; got %v", schema["counts"])
+	}
+}
+
+func TestRecursiveProofWitnessSchema(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BW6_761"), common.StringOrNil("groth16"))
+
+	schema, err := provider.WitnessSchema(zkp.RecursiveProofProver, nil)
+	if err != nil {
+		t.Fatalf("failed to resolve witness schema; %s", err.Error())
+	}
+
+	visibility := fieldVisibility(schema)
+	if visibility["PreimageHash"] != "public" {
+		t.Errorf("expected PreimageHash to be public; got %s", visibility["PreimageHash"])
+	}
+	if visibility["Proof.Ar.X"] != "secret" {
+		t.Errorf("expected Proof.Ar.X to be secret; got %s", visibility["Proof.Ar.X"])
+	}
+
+	counts := schema["counts"].([]string)
+	found := false
+	for _, count := range counts {
+		if count == "VerifyingKey.G1.K_count" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected VerifyingKey.G1.K_count count variable; got %v", counts)
+	}
+
+	schema, err = provider.WitnessSchema(zkp.RecursiveProofProver, map[string]interface{}{"VerifyingKey.G1.K_count": "2"})
+	if err != nil {
+		t.Fatalf("failed to resolve witness schema; %s", err.Error())
+	}
+
+	if _, ok := fieldVisibility(schema)["VerifyingKey.G1.K[1].X"]; !ok {
+		t.Errorf("expected allocated VerifyingKey.G1.K[1].X field")
+	}
+}
@@ -0,0 +1,231 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/accumulator/merkletree"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	bn254mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/std/accumulator/merkle"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+const testConstraintSpecProver = "invoice_check"
+
+func constraintSpecProvider(t *testing.T, raw string) *zkp.GnarkProverProvider {
+	spec, err := gnark.ParseConstraintSpec([]byte(raw))
+	if err != nil {
+		t.Fatalf("failed to parse constraint spec; %s", err.Error())
+	}
+
+	circuit, err := gnark.NewConstraintSpecCircuit(spec)
+	if err != nil {
+		t.Fatalf("failed to build constraint spec circuit; %s", err.Error())
+	}
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+	err = provider.AddProver(testConstraintSpecProver, circuit)
+	if err != nil {
+		t.Fatalf("failed to add constraint spec prover; %s", err.Error())
+	}
+
+	return provider
+}
+
+func TestConstraintSpecCircuit(t *testing.T) {
+	provider := constraintSpecProvider(t, `{
+		"constraints": [
+			{"name": "Amount", "operator": "<=", "value": "1000"},
+			{"name": "Amount", "operator": ">", "value": 10},
+			{"name": "Status", "operator": "!=", "value": "0x3"},
+			{"name": "Approved", "operator": "==", "value": true, "public": true}
+		]
+	}`)
+
+	r1cs, err := provider.Compile(provider.ProverFactory(testConstraintSpecProver))
+	if err != nil {
+		t.Fatalf("failed to compile constraint spec prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup constraint spec prover; %s", err.Error())
+	}
+
+	witval, err := provider.WitnessFactory(testConstraintSpecProver, "BN254", map[string]interface{}{
+		"Amount":   "500",
+		"Status":   1,
+		"Approved": 1,
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to prove constraint spec witness; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify constraint spec proof; %s", err.Error())
+	}
+
+	for _, witness := range []map[string]interface{}{
+		{"Amount": "1001", "Status": 1, "Approved": 1},
+		{"Amount": "10", "Status": 1, "Approved": 1},
+		{"Amount": "500", "Status": 3, "Approved": 1},
+		{"Amount": "500", "Status": 1, "Approved": 0},
+	} {
+		witval, err := provider.WitnessFactory(testConstraintSpecProver, "BN254", witness, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build witness %v; %s", witness, err.Error())
+		}
+
+		err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+		if _, ok := err.(*zkp.ConstraintError); !ok {
+			t.Fatalf("expected constraint error for witness %v; %v", witness, err)
+		}
+	}
+}
+
+func TestConstraintSpecRollup(t *testing.T) {
+	provider := constraintSpecProvider(t, `{
+		"constraints": [{"name": "Amount", "operator": ">=", "value": "1"}],
+		"rollup_proof_count": 3
+	}`)
+
+	r1cs, err := provider.Compile(provider.ProverFactory(testConstraintSpecProver))
+	if err != nil {
+		t.Fatalf("failed to compile constraint spec prover; %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < 4; i++ {
+		var leaf fr.Element
+		leaf.SetUint64(uint64(i + 1))
+		b := leaf.Bytes()
+		buf.Write(b[:])
+	}
+
+	proofIndex := uint64(1)
+	root, proofSet, numLeaves, err := merkletree.BuildReaderProof(&buf, bn254mimc.NewMiMC(), fr.Bytes, proofIndex)
+	if err != nil {
+		t.Fatalf("failed to build merkle proof; %s", err.Error())
+	}
+
+	proofs := make([]interface{}, len(proofSet))
+	for i := range proofSet {
+		proofs[i] = new(big.Int).SetBytes(proofSet[i]).String()
+	}
+
+	helpers := make([]interface{}, 0)
+	for _, helper := range merkle.GenerateProofHelper(proofSet, proofIndex, numLeaves) {
+		helpers = append(helpers, helper)
+	}
+
+	witval, err := provider.WitnessFactory(testConstraintSpecProver, "BN254", map[string]interface{}{
+		"Amount":   "1",
+		"RootHash": new(big.Int).SetBytes(root).String(),
+		"Proofs":   proofs,
+		"Helpers":  helpers,
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err != nil {
+		t.Fatalf("failed to solve rollup witness; %s", err.Error())
+	}
+
+	schema, err := provider.WitnessSchema(testConstraintSpecProver, nil)
+	if err != nil {
+		t.Fatalf("failed to resolve witness schema; %s", err.Error())
+	}
+
+	if len(schema["fields"].([]map[string]interface{})) != 1+1+3+2 {
+		t.Fatalf("unexpected witness schema fields; %v", schema["fields"])
+	}
+}
+
+func TestConstraintSpecValueLeadingZerosAreDecimal(t *testing.T) {
+	provider := constraintSpecProvider(t, `{
+		"constraints": [{"name": "Amount", "operator": "==", "value": "010"}]
+	}`)
+
+	r1cs, err := provider.Compile(provider.ProverFactory(testConstraintSpecProver))
+	if err != nil {
+		t.Fatalf("failed to compile constraint spec prover; %s", err.Error())
+	}
+
+	for _, tc := range []struct {
+		amount string
+		solved bool
+	}{
+		{"10", true},
+		{"8", false},
+	} {
+		witval, err := provider.WitnessFactory(testConstraintSpecProver, "BN254", map[string]interface{}{
+			"Amount": tc.amount,
+		}, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build witness; %s", err.Error())
+		}
+
+		err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+		if (err == nil) != tc.solved {
+			t.Fatalf("expected constraint value 010 to equal decimal 10; amount %s; %v", tc.amount, err)
+		}
+	}
+}
+
+func TestInvalidConstraintSpec(t *testing.T) {
+	for _, raw := range []string{
+		`{"constraints": []}`,
+		`{"constraints": [{"name": "Amount", "operator": "~", "value": "1"}]}`,
+		`{"constraints": [{"name": "Approved", "operator": "<", "value": true}]}`,
+		`{"constraints": [{"name": "RootHash", "operator": "==", "value": "1"}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": 1.5}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": "0b101"}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": "0o17"}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": "1_000"}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": "-1"}]}`,
+		`{"constraints": [{"name": "Amount", "operator": "==", "value": "1"}], "rollup_proof_count": 1}`,
+	} {
+		spec, err := gnark.ParseConstraintSpec([]byte(raw))
+		if err != nil {
+			continue
+		}
+
+		_, err = gnark.NewConstraintSpecCircuit(spec)
+		if err == nil {
+			t.Fatalf("expected invalid constraint spec %s", raw)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testutil
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// WriteTo returns the binary encoding of the given gnark object, i.e. a constraint system,
+// proving key, verifying key or proof; the test fails if it cannot be marshaled
+func WriteTo(t *testing.T, v interface{}) []byte {
+	buf := new(bytes.Buffer)
+	_, err := v.(io.WriterTo).WriteTo(buf)
+	if err != nil {
+		t.Fatalf("failed to marshal %T; %s", v, err.Error())
+	}
+	return buf.Bytes()
+}
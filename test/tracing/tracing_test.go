@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/nats-io/nats.go"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func requireInMemoryTracing() *tracetest.InMemoryExporter {
+	common.RequireTracing("privacy-test")
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
+
+func TestProveSpans(t *testing.T) {
+	exporter := requireInMemoryTracing()
+
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, _, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	hFunc := gnarkhash.MIMC_BN254.New()
+	hFunc.Write(new(big.Int).SetInt64(7).FillBytes(make([]byte, hFunc.BlockSize())))
+
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "7",
+		"Hash":     new(big.Int).SetBytes(hFunc.Sum(nil)).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build preimage hash witness; %s", err.Error())
+	}
+
+	exporter.Reset()
+
+	ctx, span := common.StartSpan(context.Background(), "test prove")
+	_, err = provider.Prove(ctx, testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	common.EndSpan(span, err)
+	if err != nil {
+		t.Fatalf("failed to prove preimage hash witness; %s", err.Error())
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, stub := range exporter.GetSpans() {
+		spans[stub.Name] = stub
+	}
+
+	for _, name := range []string{"gnark decode r1cs", "gnark decode proving key", "gnark prove"} {
+		stub, ok := spans[name]
+		if !ok {
+			t.Fatalf("expected %s span to be exported", name)
+		}
+
+		if stub.Parent.SpanID() != span.SpanContext().SpanID() {
+			t.Fatalf("expected %s span to be a child of the proving span", name)
+		}
+	}
+}
+
+func TestNatsTraceContext(t *testing.T) {
+	requireInMemoryTracing()
+
+	ctx, span := common.StartSpan(context.Background(), "test publish")
+	defer span.End()
+
+	msg := nats.NewMsg("privacy.prover.prove.pending")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(http.Header(msg.Header)))
+
+	extracted := trace.SpanContextFromContext(common.NatsTraceContext(msg))
+	if extracted.TraceID() != span.SpanContext().TraceID() {
+		t.Fatalf("expected trace context to be carried in NATS message headers")
+	}
+
+	if trace.SpanContextFromContext(common.NatsTraceContext(&nats.Msg{})).IsValid() {
+		t.Fatalf("expected no trace context for NATS message without headers")
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"testing"
 	"time"
 
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
 	uuid "github.com/kthomas/go.uuid"
 	"github.com/provideplatform/privacy/common"
 	"github.com/provideplatform/privacy/store/providers/merkletree"
@@ -89,10 +90,32 @@ func userTokenFactory(testID uuid.UUID) (*string, error) {
 	return token.AccessToken, nil
 }
 
+// createProver creates a prover; provers compiled asynchronously are accepted with a 202
+// response, while provers imported from artifacts are created with a 201 response
+func createProver(token string, params map[string]interface{}) (*privacy.Prover, error) {
+	status, resp, err := privacy.InitPrivacyService(token).Post("provers", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != 201 && status != 202 {
+		return nil, fmt.Errorf("failed to create prover; status: %v", status)
+	}
+
+	prover := &privacy.Prover{}
+	raw, _ := json.Marshal(resp)
+	err = json.Unmarshal(raw, &prover)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prover; %s", err.Error())
+	}
+
+	return prover, nil
+}
+
 func createProcureToPayWorkflow(token *string, provingScheme string) ([]*privacy.Prover, error) {
 	provers := make([]*privacy.Prover, 0)
 
-	prover, err := privacy.CreateProver(
+	prover, err := createProver(
 		*token,
 		proverParamsFactory(
 			"BN254",
@@ -109,7 +132,7 @@ func createProcureToPayWorkflow(token *string, provingScheme string) ([]*privacy
 	}
 	provers = append(provers, prover)
 
-	prover, err = privacy.CreateProver(
+	prover, err = createProver(
 		*token,
 		proverParamsFactory(
 			"BN254",
@@ -126,7 +149,7 @@ func createProcureToPayWorkflow(token *string, provingScheme string) ([]*privacy
 	}
 	provers = append(provers, prover)
 
-	prover, err = privacy.CreateProver(
+	prover, err = createProver(
 		*token,
 		proverParamsFactory(
 			"BN254",
@@ -143,7 +166,7 @@ func createProcureToPayWorkflow(token *string, provingScheme string) ([]*privacy
 	}
 	provers = append(provers, prover)
 
-	prover, err = privacy.CreateProver(
+	prover, err = createProver(
 		*token,
 		proverParamsFactory(
 			"BN254",
@@ -160,7 +183,7 @@ func createProcureToPayWorkflow(token *string, provingScheme string) ([]*privacy
 	}
 	provers = append(provers, prover)
 
-	prover, err = privacy.CreateProver(
+	prover, err = createProver(
 		*token,
 		proverParamsFactory(
 			"BN254",
@@ -248,6 +271,41 @@ func requireProvers(token *string, provers []*privacy.Prover) error {
 	}
 }
 
+// awaitProverStatusTransitions polls the given prover until it is provisioned or failed, returning
+// the distinct statuses observed, in order, and the raw prover details at the final status
+func awaitProverStatusTransitions(token, proverID string, interval time.Duration) ([]string, map[string]interface{}, error) {
+	statuses := make([]string, 0)
+	startTime := time.Now()
+
+	for {
+		status, resp, err := privacy.InitPrivacyService(token).Get(fmt.Sprintf("provers/%s", proverID), map[string]interface{}{})
+		if err != nil {
+			return statuses, nil, err
+		}
+
+		if status != 200 {
+			return statuses, nil, fmt.Errorf("failed to fetch prover; status: %v", status)
+		}
+
+		prover, _ := resp.(map[string]interface{})
+		if proverStatus, statusOk := prover["status"].(string); statusOk {
+			if len(statuses) == 0 || statuses[len(statuses)-1] != proverStatus {
+				statuses = append(statuses, proverStatus)
+			}
+
+			if proverStatus == "provisioned" || proverStatus == "failed" {
+				return statuses, prover, nil
+			}
+		}
+
+		if startTime.Add(requireProverTimeout).Before(time.Now()) {
+			return statuses, prover, fmt.Errorf("timed out awaiting provisioning of prover %s", proverID)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
 func getNullifier(
 	t *testing.T,
 	token *string,
@@ -363,6 +421,88 @@ func testProverLifecycle(
 	return []byte{}, nil
 }
 
+// documentWitness returns the witness, and the public witness, proving knowledge of the
+// given document payload using a procure-to-pay document prover
+func documentWitness(hFunc hash.Hash, payload map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	raw, _ := json.Marshal(payload)
+
+	hFunc.Reset()
+	hFunc.Write(raw)
+
+	var i big.Int
+
+	// preimage is itself a digest due to the field element size limitation of the curve
+	preImage := hFunc.Sum(nil)
+	preImageString := i.SetBytes(preImage).String()
+
+	mimc := gnarkhash.MIMC_BN254.New()
+	mimc.Write(preImage)
+	hashString := i.SetBytes(mimc.Sum(nil)).String()
+
+	witness := map[string]interface{}{
+		"Document": map[string]interface{}{
+			"Preimage": preImageString,
+			"Hash":     hashString,
+		},
+	}
+
+	publicWitness := map[string]interface{}{
+		"Document": map[string]interface{}{
+			"Hash": hashString,
+		},
+	}
+
+	return witness, publicWitness
+}
+
+// testDocumentProverLifecycle proves and verifies the given document payload using a
+// procure-to-pay document prover, then resolves the resulting note and, when a previous
+// prover is given, the nullified note of the previous step of the workflow
+func testDocumentProverLifecycle(
+	t *testing.T,
+	hFunc hash.Hash,
+	token *string,
+	proverIndex uint64,
+	prover *privacy.Prover,
+	prevProver *privacy.Prover,
+	payload map[string]interface{},
+) ([]byte, error) {
+	witness, publicWitness := documentWitness(hFunc, payload)
+
+	proof, err := privacy.Prove(*token, prover.ID.String(), map[string]interface{}{
+		"witness": witness,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof; %s", err.Error())
+	}
+
+	verification, err := privacy.Verify(*token, prover.ID.String(), map[string]interface{}{
+		"proof":   proof.Proof,
+		"witness": publicWitness,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify proof; %s", err.Error())
+	}
+
+	if !verification.Result {
+		return nil, fmt.Errorf("failed to verify %s proof", *prover.Name)
+	}
+
+	t.Logf("%s proof/verification: %s / %v", *prover.Name, *proof.Proof, verification.Result)
+
+	resp, err := privacy.GetNoteValue(*token, prover.ID.String(), proverIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note value; %s", err.Error())
+	}
+	t.Logf("retrieved note value at index %d; root: %s", proverIndex, *resp.Root)
+
+	if proverIndex > 0 && prevProver != nil {
+		return getNullifier(t, token, proverIndex-1, prevProver)
+	}
+
+	return []byte{}, nil
+}
+
 func proverParamsFactory(curve, name, identifier, provingScheme string, noteStoreID, nullifierStoreID *string) map[string]interface{} {
 	params := map[string]interface{}{
 		"curve":          curve,
@@ -0,0 +1,189 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/provideplatform/privacy/common"
+	deployer "github.com/provideplatform/privacy/deployer/providers"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+	"github.com/provideplatform/privacy/zkp/verifier"
+)
+
+func preimageHashWitness() map[string]interface{} {
+	preimage := big.NewInt(1234567890)
+
+	h := gnarkhash.MIMC_BN254.New()
+	h.Write(preimage.FillBytes(make([]byte, 32)))
+
+	return map[string]interface{}{
+		"Preimage": preimage.String(),
+		"Hash":     new(big.Int).SetBytes(h.Sum(nil)).String(),
+	}
+}
+
+func TestGroth16BN254ProofVerifiesOnChain(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	witness := preimageHashWitness()
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", witness, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to generate proof; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify proof off-chain; %s", err.Error())
+	}
+
+	_, _, _, err = verifier.Groth16BN254ProofCalldata(testutil.WriteTo(t, proof))
+	if err != nil {
+		t.Fatalf("failed to encode proof calldata; %s", err.Error())
+	}
+
+	if !verifier.SolcAvailable() {
+		if os.Getenv("REQUIRE_SOLC") == "true" {
+			t.Fatalf("solc required for on-chain verification but not available; run ops/install_solc.sh or set SOLC_PATH")
+		}
+		t.Skip("solc not available; skipping on-chain verification")
+	}
+
+	err = provider.VerifyOnChain(testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval)
+	if err != nil {
+		t.Fatalf("failed to verify proof on-chain; %s", err.Error())
+	}
+}
+
+func TestPublicInputsMatchWitness(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	witness := preimageHashWitness()
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", witness, nil, true)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	publicWitness, err := provider.PublicWitness(witval)
+	if err != nil {
+		t.Fatalf("failed to serialize public witness; %s", err.Error())
+	}
+
+	inputs, err := verifier.PublicInputs(publicWitness)
+	if err != nil {
+		t.Fatalf("failed to decode public inputs; %s", err.Error())
+	}
+
+	if len(inputs) != 1 || inputs[0].String() != witness["Hash"] {
+		t.Errorf("public inputs %v do not match witness hash %s", inputs, witness["Hash"])
+	}
+}
+
+func TestVerifyUsesPublicInputsOnly(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	witness := preimageHashWitness()
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", witness, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), witval, nil)
+	if err != nil {
+		t.Fatalf("failed to generate proof; %s", err.Error())
+	}
+
+	publicWitness := map[string]interface{}{
+		"Preimage": "0",
+		"Hash":     witness["Hash"],
+	}
+	pubval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", publicWitness, nil, true)
+	if err != nil {
+		t.Fatalf("failed to build public witness; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), pubval, nil)
+	if err != nil {
+		t.Errorf("failed to verify proof without private inputs; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), witval, nil)
+	if err != nil {
+		t.Errorf("failed to verify proof with full witness; %s", err.Error())
+	}
+
+	publicWitness["Hash"] = "1"
+	pubval, err = provider.WitnessFactory(zkp.PreimageHashProver, "BN254", publicWitness, nil, true)
+	if err != nil {
+		t.Fatalf("failed to build public witness; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), testutil.WriteTo(t, vk), pubval, nil)
+	if err == nil {
+		t.Errorf("verified proof against mismatched public input")
+	}
+}
+
+func TestSimulatedContractDeployerIsTestOnly(t *testing.T) {
+	enabled := common.SimulatedContractDeployerEnabled
+	defer func() {
+		common.SimulatedContractDeployerEnabled = enabled
+	}()
+
+	common.SimulatedContractDeployerEnabled = false
+	if _, err := deployer.ContractDeployerFactory(deployer.ContractDeployerSimulated); err == nil {
+		t.Errorf("resolved simulated contract deployer without opting in")
+	}
+
+	common.SimulatedContractDeployerEnabled = true
+	if _, err := deployer.ContractDeployerFactory(deployer.ContractDeployerSimulated); err != nil {
+		t.Errorf("failed to resolve enabled simulated contract deployer; %s", err.Error())
+	}
+}
@@ -0,0 +1,266 @@
+//go:build unit
+// +build unit
+
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	gnarkhash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/test/testutil"
+	zkp "github.com/provideplatform/privacy/zkp/providers"
+)
+
+func preimageHash(preimage *big.Int) *big.Int {
+	h := gnarkhash.MIMC_BN254.New()
+	h.Write(preimage.FillBytes(make([]byte, 32)))
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func setupPreimageHashProver(t *testing.T) (*zkp.GnarkProverProvider, []byte, []byte, []byte) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	pk, vk, err := provider.Setup(context.Background(), testutil.WriteTo(t, r1cs), nil)
+	if err != nil {
+		t.Fatalf("failed to setup preimage hash prover; %s", err.Error())
+	}
+
+	return provider, testutil.WriteTo(t, r1cs), testutil.WriteTo(t, pk), testutil.WriteTo(t, vk)
+}
+
+func TestWitnessValueFormats(t *testing.T) {
+	provider, r1cs, pk, vk := setupPreimageHashProver(t)
+
+	preimage := big.NewInt(1234567890)
+	hash := preimageHash(preimage)
+
+	for _, witness := range []map[string]interface{}{
+		{"Preimage": preimage.String(), "Hash": hash.String()},
+		{"Preimage": "0x" + preimage.Text(16), "Hash": "0x" + hash.Text(16)},
+		{"Preimage": float64(preimage.Int64()), "Hash": hash.String()},
+	} {
+		witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", witness, nil, false)
+		if err != nil {
+			t.Fatalf("failed to build witness %v; %s", witness, err.Error())
+		}
+
+		proof, err := provider.Prove(context.Background(), r1cs, pk, witval, nil)
+		if err != nil {
+			t.Fatalf("failed to prove witness %v; %s", witness, err.Error())
+		}
+
+		err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), vk, witval, nil)
+		if err != nil {
+			t.Fatalf("failed to verify witness %v; %s", witness, err.Error())
+		}
+	}
+}
+
+func TestWitnessValueErrors(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	for _, tc := range []struct {
+		witness map[string]interface{}
+		path    string
+	}{
+		{map[string]interface{}{"Preimage": "not-a-number", "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": 1.5, "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "1", "Missing": "1"}, "Missing"},
+		{map[string]interface{}{"Preimage": map[string]interface{}{"X": "1"}, "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "0b101", "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "0o17", "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "1_000", "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "0x", "Hash": "1"}, "Preimage"},
+		{map[string]interface{}{"Preimage": "ff", "Hash": "1"}, "Preimage"},
+	} {
+		_, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", tc.witness, nil, false)
+		if err == nil {
+			t.Fatalf("expected error for witness %v", tc.witness)
+		}
+
+		if !strings.Contains(err.Error(), tc.path) {
+			t.Fatalf("expected error for witness %v to reference field %s; %s", tc.witness, tc.path, err.Error())
+		}
+	}
+}
+
+func TestWitnessValueLeadingZerosAreDecimal(t *testing.T) {
+	provider, r1cs, pk, vk := setupPreimageHashProver(t)
+
+	preimage := big.NewInt(10)
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": "010",
+		"Hash":     preimageHash(preimage).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), r1cs, pk, witval, nil)
+	if err != nil {
+		t.Fatalf("expected 010 to be parsed as decimal 10; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), vk, witval, nil)
+	if err != nil {
+		t.Fatalf("failed to verify witness; %s", err.Error())
+	}
+}
+
+func TestNestedWitnessReportsFullPath(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BW6_761"), common.StringOrNil("groth16"))
+
+	_, err := provider.WitnessFactory(zkp.RecursiveProofProver, "BW6_761", map[string]interface{}{
+		"Proof": map[string]interface{}{
+			"Ar": map[string]interface{}{
+				"X": "1",
+				"Y": "not-a-number",
+			},
+		},
+	}, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "Proof.Ar.Y") {
+		t.Fatalf("expected error referencing Proof.Ar.Y; %v", err)
+	}
+
+	_, err = provider.WitnessFactory(zkp.RecursiveProofProver, "BW6_761", map[string]interface{}{
+		"VerifyingKey": map[string]interface{}{
+			"G1": map[string]interface{}{
+				"K": []interface{}{
+					map[string]interface{}{"X": "1", "Z": "1"},
+				},
+			},
+		},
+	}, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "VerifyingKey.G1.K[0].Z") {
+		t.Fatalf("expected error referencing VerifyingKey.G1.K[0].Z; %v", err)
+	}
+}
+
+func TestSerializedWitness(t *testing.T) {
+	provider, r1cs, pk, vk := setupPreimageHashProver(t)
+
+	preimage := big.NewInt(987654321)
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": preimage.String(),
+		"Hash":     preimageHash(preimage).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	fullWitness, err := frontend.NewWitness(witval.(frontend.Circuit), common.GnarkCurveIDFactory(common.StringOrNil("BN254")))
+	if err != nil {
+		t.Fatalf("failed to build full witness; %s", err.Error())
+	}
+	encodedFullWitness, _ := fullWitness.MarshalBinary()
+
+	publicWitness, err := provider.PublicWitness(witval)
+	if err != nil {
+		t.Fatalf("failed to build public witness; %s", err.Error())
+	}
+
+	serialized, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", hex.EncodeToString(encodedFullWitness), nil, false)
+	if err != nil {
+		t.Fatalf("failed to decode serialized witness; %s", err.Error())
+	}
+
+	proof, err := provider.Prove(context.Background(), r1cs, pk, serialized, nil)
+	if err != nil {
+		t.Fatalf("failed to prove serialized witness; %s", err.Error())
+	}
+
+	serializedPublic, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", hex.EncodeToString(publicWitness), nil, true)
+	if err != nil {
+		t.Fatalf("failed to decode serialized public witness; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), vk, serializedPublic, nil)
+	if err != nil {
+		t.Fatalf("failed to verify serialized public witness; %s", err.Error())
+	}
+
+	// the public part of a serialized full witness is used for verification
+	serializedFull, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", hex.EncodeToString(encodedFullWitness), nil, true)
+	if err != nil {
+		t.Fatalf("failed to decode serialized full witness for verification; %s", err.Error())
+	}
+
+	err = provider.Verify(context.Background(), testutil.WriteTo(t, proof), vk, serializedFull, nil)
+	if err != nil {
+		t.Fatalf("failed to verify serialized full witness; %s", err.Error())
+	}
+
+	// a serialized public witness cannot be used to generate a proof
+	_, err = provider.WitnessFactory(zkp.PreimageHashProver, "BN254", hex.EncodeToString(publicWitness), nil, false)
+	if err == nil {
+		t.Fatalf("expected serialized public witness to be rejected for proving")
+	}
+}
+
+func TestSolveWitness(t *testing.T) {
+	provider := zkp.InitGnarkProverProvider(common.StringOrNil("BN254"), common.StringOrNil("groth16"))
+
+	r1cs, err := provider.Compile(provider.ProverFactory(zkp.PreimageHashProver))
+	if err != nil {
+		t.Fatalf("failed to compile preimage hash prover; %s", err.Error())
+	}
+
+	preimage := big.NewInt(1234567890)
+	witval, err := provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": preimage.String(),
+		"Hash":     preimageHash(preimage).String(),
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	if err != nil {
+		t.Fatalf("failed to solve valid witness; %s", err.Error())
+	}
+
+	witval, err = provider.WitnessFactory(zkp.PreimageHashProver, "BN254", map[string]interface{}{
+		"Preimage": preimage.String(),
+		"Hash":     "1",
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to build witness; %s", err.Error())
+	}
+
+	err = provider.Solve(testutil.WriteTo(t, r1cs), witval)
+	constraintErr, constraintErrOk := err.(*zkp.ConstraintError)
+	if !constraintErrOk {
+		t.Fatalf("expected constraint error for invalid witness; %v", err)
+	}
+
+	if constraintErr.Source == nil || !strings.Contains(*constraintErr.Source, "preimage_hash.go") {
+		t.Fatalf("expected constraint error source to reference preimage_hash.go; %v", constraintErr.Source)
+	}
+}
@@ -16,21 +16,46 @@
 
 package gnark
 
-// // BaselineRollupProver defines a mrkle root verification proof
-// type BaselineRollupProver struct {
-// 	Proofs, Helpers []frontend.Variable
-// 	RootHash        frontend.Variable `gnark:",public"`
-// }
-
-// // Define declares the prover constraints
-// func (prover *BaselineRollupProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	// hash function
-// 	mimc, err := mimc.NewMiMC("seed", curveID)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	merkle.VerifyProof(cs, mimc, prover.RootHash, prover.Proofs, prover.Helpers)
-
-// 	return nil
-// }
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// BaselineRollupCircuit proves the note store tree with root OldRoot has root NewRoot after
+// the insertion of Leaves at consecutive indices starting at Index; each Path contains the
+// siblings of the inserted leaf at the time of its insertion, and zero leaves pad partial
+// batches, leaving the root unchanged
+type BaselineRollupCircuit struct {
+	OldRoot frontend.Variable `gnark:",public"`
+	NewRoot frontend.Variable `gnark:",public"`
+	Index   frontend.Variable `gnark:",public"`
+	Leaves  []frontend.Variable
+	Paths   [][NoteTreeDepth]frontend.Variable
+}
+
+// Define declares the circuit's constraints
+func (circuit *BaselineRollupCircuit) Define(api frontend.API) error {
+	if len(circuit.Leaves) == 0 || len(circuit.Leaves) != len(circuit.Paths) {
+		return fmt.Errorf("rollup requires an equal, non-zero number of leaves and paths; %d leaves, %d paths", len(circuit.Leaves), len(circuit.Paths))
+	}
+
+	root := circuit.OldRoot
+	for i, leaf := range circuit.Leaves {
+		index := api.Add(circuit.Index, i)
+
+		// the leaf is inserted into an empty slot of the current tree
+		err := AssertIsMerkleMember(api, root, 0, index, circuit.Paths[i][:])
+		if err != nil {
+			return err
+		}
+
+		root, err = MerkleRoot(api, leaf, index, circuit.Paths[i][:])
+		if err != nil {
+			return err
+		}
+	}
+
+	api.AssertIsEqual(circuit.NewRoot, root)
+	return nil
+}
@@ -0,0 +1,91 @@
+package gnark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// DefaultComparatorBitWidth is the bit width of compared values when none is given, i.e.,
+// sufficient for amounts, quantities and unix timestamps
+const DefaultComparatorBitWidth = 64
+
+// ValidateBitWidth returns an error if values of the given bit width cannot be compared
+// without overflowing the scalar field of the snark curve
+func ValidateBitWidth(api frontend.API, bitWidth int) error {
+	max := api.Compiler().Curve().Info().Fr.Bits - 2
+	if bitWidth < 1 || bitWidth > max {
+		return fmt.Errorf("invalid bit width %d; must be between 1 and %d", bitWidth, max)
+	}
+	return nil
+}
+
+// AssertBitWidth asserts the given value fits in bitWidth bits
+func AssertBitWidth(api frontend.API, v frontend.Variable, bitWidth int) {
+	api.ToBinary(v, bitWidth)
+}
+
+// IsLessOrEqual returns 1 if a <= b, 0 otherwise; a and b are constrained to bitWidth bits
+func IsLessOrEqual(api frontend.API, a, b frontend.Variable, bitWidth int) frontend.Variable {
+	AssertBitWidth(api, a, bitWidth)
+	AssertBitWidth(api, b, bitWidth)
+
+	// b - a + 2^bitWidth has its most significant bit set iff a <= b
+	offset := new(big.Int).Lsh(big.NewInt(1), uint(bitWidth))
+	bits := api.ToBinary(api.Add(api.Sub(b, a), offset), bitWidth+1)
+	return bits[bitWidth]
+}
+
+// IsLess returns 1 if a < b, 0 otherwise; a and b are constrained to bitWidth bits
+func IsLess(api frontend.API, a, b frontend.Variable, bitWidth int) frontend.Variable {
+	return api.Sub(1, IsLessOrEqual(api, b, a, bitWidth))
+}
+
+// IsGreaterOrEqual returns 1 if a >= b, 0 otherwise; a and b are constrained to bitWidth bits
+func IsGreaterOrEqual(api frontend.API, a, b frontend.Variable, bitWidth int) frontend.Variable {
+	return IsLessOrEqual(api, b, a, bitWidth)
+}
+
+// IsGreater returns 1 if a > b, 0 otherwise; a and b are constrained to bitWidth bits
+func IsGreater(api frontend.API, a, b frontend.Variable, bitWidth int) frontend.Variable {
+	return IsLess(api, b, a, bitWidth)
+}
+
+// IsInRange returns 1 if min <= v <= max, 0 otherwise; v, min and max are constrained to bitWidth bits
+func IsInRange(api frontend.API, v, min, max frontend.Variable, bitWidth int) frontend.Variable {
+	return api.And(
+		IsLessOrEqual(api, min, v, bitWidth),
+		IsLessOrEqual(api, v, max, bitWidth),
+	)
+}
+
+// AssertIsNotEqual asserts a != b
+func AssertIsNotEqual(api frontend.API, a, b frontend.Variable) {
+	api.AssertIsEqual(api.IsZero(api.Sub(a, b)), 0)
+}
+
+// AssertIsLessOrEqual asserts a <= b; a and b are constrained to bitWidth bits
+func AssertIsLessOrEqual(api frontend.API, a, b frontend.Variable, bitWidth int) {
+	api.AssertIsEqual(IsLessOrEqual(api, a, b, bitWidth), 1)
+}
+
+// AssertIsLess asserts a < b; a and b are constrained to bitWidth bits
+func AssertIsLess(api frontend.API, a, b frontend.Variable, bitWidth int) {
+	api.AssertIsEqual(IsLess(api, a, b, bitWidth), 1)
+}
+
+// AssertIsGreaterOrEqual asserts a >= b; a and b are constrained to bitWidth bits
+func AssertIsGreaterOrEqual(api frontend.API, a, b frontend.Variable, bitWidth int) {
+	api.AssertIsEqual(IsGreaterOrEqual(api, a, b, bitWidth), 1)
+}
+
+// AssertIsGreater asserts a > b; a and b are constrained to bitWidth bits
+func AssertIsGreater(api frontend.API, a, b frontend.Variable, bitWidth int) {
+	api.AssertIsEqual(IsGreater(api, a, b, bitWidth), 1)
+}
+
+// AssertIsInRange asserts min <= v <= max; v, min and max are constrained to bitWidth bits
+func AssertIsInRange(api frontend.API, v, min, max frontend.Variable, bitWidth int) {
+	api.AssertIsEqual(IsInRange(api, v, min, max, bitWidth), 1)
+}
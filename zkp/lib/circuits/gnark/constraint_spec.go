@@ -0,0 +1,292 @@
+package gnark
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/accumulator/merkle"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/provideplatform/privacy/common"
+)
+
+const constraintSpecRootHash = "RootHash"
+const constraintSpecProofs = "Proofs"
+const constraintSpecHelpers = "Helpers"
+
+var constraintSpecNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// Constraint is a single assertion on a named witness value, i.e., {"name": "Amount", "operator": "<=", "value": "1000"}
+type Constraint struct {
+	Name     string      `json:"name"`
+	Operator string      `json:"operator"` // can be one of ==, !=, <=, <, >= or >
+	Value    interface{} `json:"value"`    // integer as a number or decimal/hex string, or bool (== and != only)
+	Public   bool        `json:"public"`
+}
+
+// ConstraintSpec is a runtime circuit definition; when RollupProofCount is non-zero, the
+// circuit additionally verifies a MiMC merkle proof of the given length against RootHash
+type ConstraintSpec struct {
+	Constraints      []*Constraint `json:"constraints"`
+	RollupProofCount int           `json:"rollup_proof_count,omitempty"`
+}
+
+// NamedWitnessField is a witness field resolved by name, rather than by struct field
+type NamedWitnessField struct {
+	Name   string
+	Public bool
+	Value  reflect.Value
+}
+
+// ConstraintSpecCircuit interprets a ConstraintSpec; witness values are assigned by constraint
+// name, and RootHash, Proofs and Helpers when a rollup proof is verified
+type ConstraintSpecCircuit struct {
+	Public []frontend.Variable `gnark:",public"`
+	Secret []frontend.Variable
+
+	spec   *ConstraintSpec
+	values []*big.Int
+	index  map[string]int // constraint name to index within Public or Secret
+}
+
+// ParseConstraintSpec parses and validates the given JSON constraint spec
+func ParseConstraintSpec(raw []byte) (*ConstraintSpec, error) {
+	var spec *ConstraintSpec
+	err := json.Unmarshal(raw, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse constraint spec; %s", err.Error())
+	}
+
+	if spec == nil {
+		return nil, fmt.Errorf("failed to parse constraint spec; spec required")
+	}
+
+	return spec, nil
+}
+
+// NewConstraintSpecCircuit validates the given spec and initializes a circuit which interprets it
+func NewConstraintSpecCircuit(spec *ConstraintSpec) (*ConstraintSpecCircuit, error) {
+	if len(spec.Constraints) == 0 && spec.RollupProofCount == 0 {
+		return nil, fmt.Errorf("invalid constraint spec; at least one constraint or rollup proof required")
+	}
+
+	if spec.RollupProofCount < 0 || spec.RollupProofCount == 1 {
+		return nil, fmt.Errorf("invalid constraint spec; rollup proof count must be zero or at least 2")
+	}
+
+	circuit := &ConstraintSpecCircuit{
+		spec:   spec,
+		values: make([]*big.Int, len(spec.Constraints)),
+		index:  map[string]int{},
+	}
+
+	reserved := map[string]bool{
+		constraintSpecRootHash: true,
+		constraintSpecProofs:   true,
+		constraintSpecHelpers:  true,
+	}
+
+	nbPublic := 0
+	nbSecret := 0
+
+	for i, constraint := range spec.Constraints {
+		if constraint == nil {
+			return nil, fmt.Errorf("invalid constraint spec; constraint %d is empty", i)
+		}
+
+		if !constraintSpecNamePattern.MatchString(constraint.Name) {
+			return nil, fmt.Errorf("invalid constraint spec; constraint %d name %q must be alphanumeric", i, constraint.Name)
+		}
+
+		if reserved[constraint.Name] {
+			return nil, fmt.Errorf("invalid constraint spec; constraint %d name %s is reserved", i, constraint.Name)
+		}
+
+		val, restricted, err := constraintValue(constraint.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint spec; constraint %s; %s", constraint.Name, err.Error())
+		}
+
+		switch constraint.Operator {
+		case "==", "!=":
+		case "<=", "<", ">=", ">":
+			if restricted {
+				return nil, fmt.Errorf("invalid constraint spec; constraint %s; operator %s not supported for boolean values", constraint.Name, constraint.Operator)
+			}
+
+			if constraint.Operator == "<" && val.Sign() == 0 {
+				return nil, fmt.Errorf("invalid constraint spec; constraint %s can never be satisfied", constraint.Name)
+			}
+		default:
+			return nil, fmt.Errorf("invalid constraint spec; constraint %s; invalid operator %s", constraint.Name, constraint.Operator)
+		}
+
+		circuit.values[i] = val
+
+		if _, exists := circuit.index[constraint.Name]; !exists {
+			if constraint.Public {
+				circuit.index[constraint.Name] = nbPublic
+				nbPublic++
+			} else {
+				circuit.index[constraint.Name] = nbSecret
+				nbSecret++
+			}
+		} else if circuit.isPublic(constraint.Name) != constraint.Public {
+			return nil, fmt.Errorf("invalid constraint spec; constraint %s visibility is inconsistent", constraint.Name)
+		}
+	}
+
+	if spec.RollupProofCount > 0 {
+		nbPublic++                              // RootHash
+		nbSecret += spec.RollupProofCount*2 - 1 // Proofs and Helpers
+	}
+
+	circuit.Public = make([]frontend.Variable, nbPublic)
+	circuit.Secret = make([]frontend.Variable, nbSecret)
+
+	return circuit, nil
+}
+
+// constraintValue parses the given constraint value; restricted values, i.e. booleans,
+// can only be used with the == and != operators
+func constraintValue(val interface{}) (*big.Int, bool, error) {
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return big.NewInt(1), true, nil
+		}
+		return big.NewInt(0), true, nil
+	case float64:
+		if v != float64(int64(v)) || v < 0 {
+			return nil, false, fmt.Errorf("value %v must be a non-negative integer", v)
+		}
+		return big.NewInt(int64(v)), false, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return big.NewInt(1), true, nil
+		case "false":
+			return big.NewInt(0), true, nil
+		}
+
+		i, ok := common.ParseInteger(v)
+		if !ok || i.Sign() < 0 {
+			return nil, false, fmt.Errorf("value %s must be a non-negative decimal or hex integer", v)
+		}
+		return i, false, nil
+	}
+
+	return nil, false, fmt.Errorf("unsupported value type %T", val)
+}
+
+func (circuit *ConstraintSpecCircuit) isPublic(name string) bool {
+	for _, constraint := range circuit.spec.Constraints {
+		if constraint.Name == name {
+			return constraint.Public
+		}
+	}
+	return false
+}
+
+func (circuit *ConstraintSpecCircuit) variable(name string) frontend.Variable {
+	if circuit.isPublic(name) {
+		return circuit.Public[circuit.index[name]]
+	}
+	return circuit.Secret[circuit.index[name]]
+}
+
+// WitnessFields returns the named witness fields of the circuit
+func (circuit *ConstraintSpecCircuit) WitnessFields() []*NamedWitnessField {
+	fields := make([]*NamedWitnessField, 0)
+	seen := map[string]bool{}
+
+	public := reflect.ValueOf(circuit.Public)
+	secret := reflect.ValueOf(circuit.Secret)
+
+	for _, constraint := range circuit.spec.Constraints {
+		if seen[constraint.Name] {
+			continue
+		}
+		seen[constraint.Name] = true
+
+		field := &NamedWitnessField{
+			Name:   constraint.Name,
+			Public: constraint.Public,
+		}
+		if constraint.Public {
+			field.Value = public.Index(circuit.index[constraint.Name])
+		} else {
+			field.Value = secret.Index(circuit.index[constraint.Name])
+		}
+		fields = append(fields, field)
+	}
+
+	if circuit.spec.RollupProofCount > 0 {
+		n := circuit.spec.RollupProofCount
+		offset := secret.Len() - (n*2 - 1)
+
+		fields = append(fields, &NamedWitnessField{
+			Name:   constraintSpecRootHash,
+			Public: true,
+			Value:  public.Index(public.Len() - 1),
+		}, &NamedWitnessField{
+			Name:  constraintSpecProofs,
+			Value: secret.Slice(offset, offset+n),
+		}, &NamedWitnessField{
+			Name:  constraintSpecHelpers,
+			Value: secret.Slice(offset+n, secret.Len()),
+		})
+	}
+
+	return fields
+}
+
+// Define the constraint spec circuit
+func (circuit *ConstraintSpecCircuit) Define(api frontend.API) error {
+	if circuit.spec.RollupProofCount > 0 {
+		n := circuit.spec.RollupProofCount
+		offset := len(circuit.Secret) - (n*2 - 1)
+
+		mimc, err := mimc.NewMiMC(api)
+		if err != nil {
+			return err
+		}
+
+		merkle.VerifyProof(
+			api,
+			mimc,
+			circuit.Public[len(circuit.Public)-1],
+			circuit.Secret[offset:offset+n],
+			circuit.Secret[offset+n:],
+		)
+	}
+
+	for i, constraint := range circuit.spec.Constraints {
+		v := circuit.variable(constraint.Name)
+		val := circuit.values[i]
+
+		switch constraint.Operator {
+		case "==":
+			api.AssertIsEqual(v, val)
+		case "!=":
+			api.AssertIsEqual(api.IsZero(api.Sub(v, val)), 0)
+		case "<=":
+			api.AssertIsLessOrEqual(v, val)
+		case "<":
+			api.AssertIsLessOrEqual(v, new(big.Int).Sub(val, big.NewInt(1)))
+		case ">=":
+			// gnark cannot bound a constant by a variable using AssertIsLessOrEqual
+			api.AssertIsEqual(api.IsZero(api.Add(api.Cmp(v, val), 1)), 0)
+		case ">":
+			api.AssertIsEqual(api.Cmp(v, val), 1)
+		default:
+			return fmt.Errorf("invalid operator %s for constraint %s", constraint.Operator, constraint.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package gnark
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// Document proves knowledge of the preimage of a MiMC-hashed document;
+// the preimage is itself a digest of the document due to the field element size limitation of the curve
+type Document struct {
+	Preimage frontend.Variable // pre-image of the document hash known to the prover only
+	Hash     frontend.Variable `gnark:",public"`
+}
+
+// define asserts Hash = mimc(Preimage)
+func (doc *Document) define(api frontend.API) error {
+	mimc, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	mimc.Write(doc.Preimage)
+	api.AssertIsEqual(doc.Hash, mimc.Sum())
+	return nil
+}
+
+// DocumentCircuit proves knowledge of a procure-to-pay document, i.e., a purchase order, sales order,
+// shipment notification, goods receipt or invoice; the document types share the same constraints
+// and are distinguished only by prover identifier
+type DocumentCircuit struct {
+	Document Document
+}
+
+// Define the document circuit
+func (circuit *DocumentCircuit) Define(api frontend.API) error {
+	return circuit.Document.define(api)
+}
@@ -0,0 +1,65 @@
+package gnark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// NoteTreeDepth is the fixed depth of MiMC note store merkle trees; membership proofs
+// provide one sibling per level, ordered from the leaves to the root
+const NoteTreeDepth = 20
+
+// NoteMembershipCircuit proves the public Commitment is a leaf of the note store tree with
+// the public Root, without revealing its Index
+type NoteMembershipCircuit struct {
+	Root       frontend.Variable `gnark:",public"`
+	Commitment frontend.Variable `gnark:",public"`
+	Index      frontend.Variable
+	Path       [NoteTreeDepth]frontend.Variable
+}
+
+// Define declares the circuit's constraints
+func (circuit *NoteMembershipCircuit) Define(api frontend.API) error {
+	return AssertIsMerkleMember(api, circuit.Root, circuit.Commitment, circuit.Index, circuit.Path[:])
+}
+
+// MerkleRoot computes the root of the tree containing leaf at index given the sibling at each
+// level; parents are hashed as MiMC(left, right) and the bits of index, least significant
+// first, indicate whether the node is the right child at each level
+func MerkleRoot(api frontend.API, leaf, index frontend.Variable, path []frontend.Variable) (frontend.Variable, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("merkle path required")
+	}
+
+	hFunc, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := api.ToBinary(index, len(path))
+	node := leaf
+
+	for i, sibling := range path {
+		left := api.Select(bits[i], sibling, node)
+		right := api.Select(bits[i], node, sibling)
+
+		hFunc.Reset()
+		hFunc.Write(left, right)
+		node = hFunc.Sum()
+	}
+
+	return node, nil
+}
+
+// AssertIsMerkleMember asserts leaf is at index of the tree with the given root
+func AssertIsMerkleMember(api frontend.API, root, leaf, index frontend.Variable, path []frontend.Variable) error {
+	computed, err := MerkleRoot(api, leaf, index, path)
+	if err != nil {
+		return err
+	}
+
+	api.AssertIsEqual(root, computed)
+	return nil
+}
@@ -16,50 +16,101 @@
 
 package gnark
 
-// import (
-// 	"math/big"
-
-// 	"github.com/consensys/gnark-crypto/ecc"
-// 	cs "github.com/consensys/gnark/frontend/compiled"
-// 	"github.com/consensys/gnark/std/algebra/twistededwards"
-// 	"github.com/consensys/gnark/std/signature/eddsa"
-// )
-
-// // OwnershipSkProver defines prover for prove of ownership of sk
-// type OwnershipSkProver struct {
-// 	Pk eddsa.PublicKey `gnark:",public"`
-// 	Sk EddsaPrivateKey
-// }
-
-// // Define declares the prover's constraints
-// func (prover *OwnershipSkProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	params, err := twistededwards.NewEdCurve(curveID)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	prover.Pk.Curve = params
-
-// 	var i big.Int
-// 	if curveID == ecc.BW6_761 {
-// 		// two chunks of 192bits each
-// 		i.SetString("6277101735386680763835789423207666416102355444464034512896", 10) // 2**192
-// 	} else {
-// 		// two chunks of 128bits each
-// 		i.SetString("340282366920938463463374607431768211456", 10) // 2**128
-// 	}
-// 	scalar := cs.Constant(i)
-
-// 	computedPk := twistededwards.Point{}
-// 	computedPk.ScalarMulFixedBase(cs, prover.Pk.Curve.BaseX, prover.Pk.Curve.BaseY, prover.Sk.Upper, prover.Pk.Curve)
-// 	computedPk.ScalarMulNonFixedBase(cs, &computedPk, scalar, prover.Pk.Curve)
-// 	lower := twistededwards.Point{}
-// 	lower.ScalarMulFixedBase(cs, prover.Pk.Curve.BaseX, prover.Pk.Curve.BaseY, prover.Sk.Lower, prover.Pk.Curve)
-
-// 	computedPk.AddGeneric(cs, &lower, &computedPk, prover.Pk.Curve)
-// 	computedPk.MustBeOnCurve(cs, prover.Pk.Curve)
-
-// 	cs.AssertIsEqual(prover.Pk.A.X, computedPk.X)
-// 	cs.AssertIsEqual(prover.Pk.A.Y, computedPk.Y)
-
-// 	return nil
-// }
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// EddsaPrivateKey defines eddsa private key in two chunks (upper and lower); the secret
+// scalar does not fit in a single field element of the snark curve
+type EddsaPrivateKey struct {
+	Upper frontend.Variable
+	Lower frontend.Variable
+}
+
+// OwnershipSkCircuit proves ownership of the secret key corresponding to the public key
+type OwnershipSkCircuit struct {
+	Pk eddsa.PublicKey `gnark:",public"`
+	Sk EddsaPrivateKey
+}
+
+// Define declares the circuit's constraints
+func (circuit *OwnershipSkCircuit) Define(api frontend.API) error {
+	curve, err := twistedEdwardsCurve(api)
+	if err != nil {
+		return err
+	}
+
+	circuit.Sk.assertPublicKey(curve, circuit.Pk, api.Compiler().Curve())
+	return nil
+}
+
+// Assign is a helper to assign a binary eddsa private key, as encoded by gnark-crypto,
+// i.e. publicKey || scalar || randSrc, into its upper and lower scalar chunks
+func (sk *EddsaPrivateKey) Assign(curveID ecc.ID, buf []byte) error {
+	size := curveID.Info().Fr.Bytes
+	if len(buf) < size*2 {
+		return fmt.Errorf("invalid %d-byte eddsa private key for curve %s", len(buf), curveID.String())
+	}
+
+	scalar := new(big.Int).SetBytes(buf[size : size*2])
+	bits := eddsaPrivateKeyChunkBits(curveID)
+
+	sk.Upper = new(big.Int).Rsh(scalar, bits)
+	sk.Lower = new(big.Int).Sub(scalar, new(big.Int).Lsh(new(big.Int).Rsh(scalar, bits), bits))
+	return nil
+}
+
+// assertPublicKey asserts pk = [Upper * 2^bits + Lower] * base
+func (sk *EddsaPrivateKey) assertPublicKey(curve twistededwards.Curve, pk eddsa.PublicKey, curveID ecc.ID) {
+	base := twistededwards.Point{
+		X: curve.Params().Base[0],
+		Y: curve.Params().Base[1],
+	}
+
+	computed := curve.ScalarMul(base, sk.Upper)
+	for i := uint(0); i < eddsaPrivateKeyChunkBits(curveID); i++ {
+		computed = curve.Double(computed)
+	}
+	computed = curve.Add(computed, curve.ScalarMul(base, sk.Lower))
+	curve.AssertIsOnCurve(computed)
+
+	curve.API().AssertIsEqual(pk.A.X, computed.X)
+	curve.API().AssertIsEqual(pk.A.Y, computed.Y)
+}
+
+// eddsaPrivateKeyChunkBits returns the size of each private key chunk, i.e., two chunks of
+// 128 bits each on curves with a 256-bit scalar field, or 192 bits each on BW6_761
+func eddsaPrivateKeyChunkBits(curveID ecc.ID) uint {
+	return uint(curveID.Info().Fr.Bytes * 4)
+}
+
+// twistedEdwardsCurve returns the twisted edwards curve embedded in the snark curve
+func twistedEdwardsCurve(api frontend.API) (twistededwards.Curve, error) {
+	var id tedwards.ID
+
+	switch api.Compiler().Curve() {
+	case ecc.BN254:
+		id = tedwards.BN254
+	case ecc.BLS12_377:
+		id = tedwards.BLS12_377
+	case ecc.BLS12_381:
+		id = tedwards.BLS12_381
+	case ecc.BLS24_315:
+		id = tedwards.BLS24_315
+	case ecc.BW6_633:
+		id = tedwards.BW6_633
+	case ecc.BW6_761:
+		id = tedwards.BW6_761
+	default:
+		return nil, fmt.Errorf("twisted edwards curve not supported for curve %s", api.Compiler().Curve().String())
+	}
+
+	return twistededwards.NewEdCurve(api, id)
+}
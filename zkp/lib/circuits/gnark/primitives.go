@@ -16,83 +16,146 @@
 
 package gnark
 
-// // RelationProver defines generic relation R between Val and RelVal
-// type RelationProver struct {
-// 	Val    frontend.Variable `gnark:",public"`
-// 	RelVal frontend.Variable
-// }
-
-// // EqualProver defines an equality verification prover
-// type EqualProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *EqualProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	cs.AssertIsLessOrEqual(prover.Vals.Val, prover.Vals.RelVal)
-// 	cs.AssertIsLessOrEqual(prover.Vals.RelVal, prover.Vals.Val) // AssertIsEqual having trouble with this prover, this is a workaround
-// 	//diff := cs.Sub(prover.Vals.Val, prover.Vals.RelVal)
-// 	//diffIsZero := cs.IsZero(diff, curveID)
-// 	//cs.AssertIsEqual(diffIsZero, cs.Constant(1))
-// 	return nil
-// }
-
-// // NotEqualProver defines an inequality verification prover
-// type NotEqualProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *NotEqualProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	diff := cs.Sub(prover.Vals.Val, prover.Vals.RelVal)
-// 	diffIsZero := cs.IsZero(diff, curveID)
-// 	cs.AssertIsEqual(diffIsZero, cs.Constant(0))
-// 	return nil
-// }
-
-// // LessOrEqualProver defines a <= verification prover
-// type LessOrEqualProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *LessOrEqualProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	cs.AssertIsLessOrEqual(prover.Vals.Val, prover.Vals.RelVal)
-// 	return nil
-// }
-
-// // GreaterOrEqualProver defines a >= verification prover
-// type GreaterOrEqualProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *GreaterOrEqualProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	cs.AssertIsLessOrEqual(prover.Vals.RelVal, prover.Vals.Val)
-// 	return nil
-// }
-
-// // LessProver defines a < verification prover
-// type LessProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *LessProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	cs.AssertIsLessOrEqual(prover.Vals.Val, cs.Sub(prover.Vals.RelVal, 1))
-// 	return nil
-// }
-
-// // GreaterProver defines a > verification prover
-// type GreaterProver struct {
-// 	Vals RelationProver
-// }
-
-// // Define declares the prover constraints
-// func (prover *GreaterProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	cs.AssertIsLessOrEqual(prover.Vals.RelVal, cs.Sub(prover.Vals.Val, 1))
-// 	return nil
-// }
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// Relation defines generic relation R between the public Val and the private RelVal, i.e., Val R RelVal
+type Relation struct {
+	Val    frontend.Variable `gnark:",public"`
+	RelVal frontend.Variable
+}
+
+// EqualCircuit defines an equality verification circuit
+type EqualCircuit struct {
+	Vals Relation
+}
+
+// Define declares the circuit constraints
+func (circuit *EqualCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.Vals.Val, circuit.Vals.RelVal)
+	return nil
+}
+
+// NotEqualCircuit defines an inequality verification circuit
+type NotEqualCircuit struct {
+	Vals Relation
+}
+
+// Define declares the circuit constraints
+func (circuit *NotEqualCircuit) Define(api frontend.API) error {
+	AssertIsNotEqual(api, circuit.Vals.Val, circuit.Vals.RelVal)
+	return nil
+}
+
+// LessOrEqualCircuit defines a <= verification circuit; values are bounded to
+// BitWidth bits, or DefaultComparatorBitWidth when not set at compile time
+type LessOrEqualCircuit struct {
+	Vals     Relation
+	BitWidth int `gnark:"-"`
+}
+
+// Define declares the circuit constraints
+func (circuit *LessOrEqualCircuit) Define(api frontend.API) error {
+	bitWidth, err := comparatorBitWidth(api, circuit.BitWidth)
+	if err != nil {
+		return err
+	}
+
+	AssertIsLessOrEqual(api, circuit.Vals.Val, circuit.Vals.RelVal, bitWidth)
+	return nil
+}
+
+// GreaterOrEqualCircuit defines a >= verification circuit; values are bounded to
+// BitWidth bits, or DefaultComparatorBitWidth when not set at compile time
+type GreaterOrEqualCircuit struct {
+	Vals     Relation
+	BitWidth int `gnark:"-"`
+}
+
+// Define declares the circuit constraints
+func (circuit *GreaterOrEqualCircuit) Define(api frontend.API) error {
+	bitWidth, err := comparatorBitWidth(api, circuit.BitWidth)
+	if err != nil {
+		return err
+	}
+
+	AssertIsGreaterOrEqual(api, circuit.Vals.Val, circuit.Vals.RelVal, bitWidth)
+	return nil
+}
+
+// LessCircuit defines a < verification circuit; values are bounded to
+// BitWidth bits, or DefaultComparatorBitWidth when not set at compile time
+type LessCircuit struct {
+	Vals     Relation
+	BitWidth int `gnark:"-"`
+}
+
+// Define declares the circuit constraints
+func (circuit *LessCircuit) Define(api frontend.API) error {
+	bitWidth, err := comparatorBitWidth(api, circuit.BitWidth)
+	if err != nil {
+		return err
+	}
+
+	AssertIsLess(api, circuit.Vals.Val, circuit.Vals.RelVal, bitWidth)
+	return nil
+}
+
+// GreaterCircuit defines a > verification circuit; values are bounded to
+// BitWidth bits, or DefaultComparatorBitWidth when not set at compile time
+type GreaterCircuit struct {
+	Vals     Relation
+	BitWidth int `gnark:"-"`
+}
+
+// Define declares the circuit constraints
+func (circuit *GreaterCircuit) Define(api frontend.API) error {
+	bitWidth, err := comparatorBitWidth(api, circuit.BitWidth)
+	if err != nil {
+		return err
+	}
+
+	AssertIsGreater(api, circuit.Vals.Val, circuit.Vals.RelVal, bitWidth)
+	return nil
+}
+
+// RangeCircuit proves a private value, i.e., an amount, date or quantity, lies within the
+// public bounds Min <= Value <= Max; values are bounded to BitWidth bits, or
+// DefaultComparatorBitWidth when not set at compile time
+type RangeCircuit struct {
+	Value    frontend.Variable
+	Min      frontend.Variable `gnark:",public"`
+	Max      frontend.Variable `gnark:",public"`
+	BitWidth int               `gnark:"-"`
+}
+
+// Define declares the circuit constraints
+func (circuit *RangeCircuit) Define(api frontend.API) error {
+	bitWidth, err := comparatorBitWidth(api, circuit.BitWidth)
+	if err != nil {
+		return err
+	}
+
+	AssertIsInRange(api, circuit.Value, circuit.Min, circuit.Max, bitWidth)
+	return nil
+}
+
+// comparatorBitWidth returns the validated bit width, defaulting to DefaultComparatorBitWidth
+func comparatorBitWidth(api frontend.API, bitWidth int) (int, error) {
+	if bitWidth == 0 {
+		bitWidth = DefaultComparatorBitWidth
+	}
+
+	err := ValidateBitWidth(api, bitWidth)
+	if err != nil {
+		return 0, err
+	}
+
+	return bitWidth, nil
+}
 
 // // ProofHashProver defines hash(Proof[]) == Hash
 // type ProofHashProver struct {
@@ -113,28 +176,28 @@ package gnark
 // 	return nil
 // }
 
-// // ProofEddsaProver defines eddsa.Verify(hash(Msg[])) of PubKey and Sig
-// type ProofEddsaProver struct {
-// 	Msg    [32]frontend.Variable
-// 	PubKey eddsa.PublicKey `gnark:",public"`
-// 	Sig    eddsa.Signature `gnark:",public"`
-// }
+// ProofEddsaCircuit defines eddsa.Verify(hash(Msg[])) of PubKey and Sig
+type ProofEddsaCircuit struct {
+	Msg    [32]frontend.Variable
+	PubKey eddsa.PublicKey `gnark:",public"`
+	Sig    eddsa.Signature `gnark:",public"`
+}
 
-// // Define declares the ProofEddsaProver prover constraints
-// func (prover *ProofEddsaProver) Define(curveID ecc.ID, cs *cs.ConstraintSystem) error {
-// 	curve, err := twistededwards.NewEdCurve(curveID)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	prover.PubKey.Curve = curve
+// Define declares the ProofEddsaCircuit constraints
+func (circuit *ProofEddsaCircuit) Define(api frontend.API) error {
+	curve, err := twistedEdwardsCurve(api)
+	if err != nil {
+		return err
+	}
 
-// 	hFunc, err := mimc.NewMiMC("seed", curveID)
-// 	if err != nil {
-// 		return err
-// 	}
+	hFunc, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
 
-// 	hash := hFunc.Hash(cs, prover.Msg[:]...)
-// 	eddsa.Verify(cs, prover.Sig, hash, prover.PubKey)
+	hFunc.Write(circuit.Msg[:]...)
+	hash := hFunc.Sum()
+	hFunc.Reset()
 
-// 	return nil
-// }
+	return eddsa.Verify(curve, circuit.Sig, hash, circuit.PubKey, &hFunc)
+}
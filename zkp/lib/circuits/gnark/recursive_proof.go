@@ -1,13 +1,26 @@
 package gnark
 
 import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/groth16_bls12377"
 	"github.com/consensys/gnark/std/hash/mimc"
 )
 
-// const recursiveProofCurve = ecc.BW6_761
+// RecursiveProofInnerCurve is the curve of the inner proof verified by the recursive proof circuit
+const RecursiveProofInnerCurve = ecc.BLS12_377
+
+// RecursiveProofCurve is the curve on which the recursive proof circuit is compiled
+const RecursiveProofCurve = ecc.BW6_761
 
+// RecursiveProofCircuit verifies a groth16 BLS12_377 proof having a single public input, Hash,
+// in addition to proving knowledge of the preimage of PreimageHash
 type RecursiveProofCircuit struct {
 	Preimage     frontend.Variable
 	PreimageHash frontend.Variable `gnark:",public"`
@@ -36,21 +49,35 @@ func (prover *RecursiveProofCircuit) Define(api frontend.API) error {
 	return nil
 }
 
-// verifyingKeyFactory initializes the given verifying key on curve BLS12_377
-// func (prover *RecursiveProofCircuit) verifyingKeyFactory() groth16_bls12377.VerifyingKey {
-// 	// buf := make([]byte, 0)
-// 	// i, err := hex.Decode(prover.VerifyingKey, buf)
-// 	// if err != nil {
-// 	// 	common.Log.Warningf("failed to decode verifying key; %s", err.Error())
-// 	// }
+// AssignInnerProof assigns the given binary-encoded groth16 BLS12_377 proof and verifying key,
+// and the public inputs of the inner proof, to the circuit
+func (prover *RecursiveProofCircuit) AssignInnerProof(proof, verifyingKey []byte, publicInputs []*big.Int) error {
+	vk := groth16.NewVerifyingKey(RecursiveProofInnerCurve)
+	_, err := vk.ReadFrom(bytes.NewReader(verifyingKey))
+	if err != nil {
+		return fmt.Errorf("failed to read inner verifying key; %s", err.Error())
+	}
+
+	if vk.NbPublicWitness() != 1 || len(publicInputs) != 1 {
+		return fmt.Errorf("failed to assign inner proof; exactly one public input supported; verifying key expects %d; %d provided", vk.NbPublicWitness(), len(publicInputs))
+	}
 
-// 	_vk, _ := prover.VerifyingKey.([]byte)
-// 	// common.Log.Debugf("read %d-byte witness-supplied verifying key", i)
+	var ar, krs bls12377.G1Affine
+	var bs bls12377.G2Affine
 
-// 	vk := groth16.NewVerifyingKey(ecc.BLS12_377)
-// 	vk.UnsafeReadFrom(bytes.NewReader(_vk))
+	dec := bls12377.NewDecoder(bytes.NewReader(proof))
+	for _, p := range []interface{}{&ar, &bs, &krs} {
+		err = dec.Decode(p)
+		if err != nil {
+			return fmt.Errorf("failed to read inner proof; %s", err.Error())
+		}
+	}
 
-// 	var verifyingKey groth16_bls12377.VerifyingKey
-// 	verifyingKey.Assign(vk)
-// 	return verifyingKey
-// }
+	prover.Proof.Ar.Assign(&ar)
+	prover.Proof.Bs.Assign(&bs)
+	prover.Proof.Krs.Assign(&krs)
+	prover.VerifyingKey.Assign(vk)
+	prover.Hash = publicInputs[0]
+
+	return nil
+}
@@ -16,6 +16,12 @@
 
 package providers
 
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
 const PreimageHashProver = "preimage_hash"
 
 const RecursiveProofProver = "recursive_proof"
@@ -23,38 +29,62 @@ const RecursiveProofProver = "recursive_proof"
 // // GnarkProverIdentifierCubic gnark cubic prover
 // const GnarkProverIdentifierCubic = "cubic"
 
-// // GnarkProverIdentifierOwnershipSk gnark ownership sk prover
-// const GnarkProverIdentifierOwnershipSk = "ownership_sk"
+// OwnershipSkProver eddsa secret key ownership prover
+const OwnershipSkProver = "ownership_sk"
 
 // // GnarkProverIdentifierMimc gnark mimc prover
 // const GnarkProverIdentifierMimc = "mimc"
 
-// // GnarkProverIdentifierBaselineDocumentComplete axiom document complete prover
-// const GnarkProverIdentifierBaselineDocumentComplete = "axiom_document_complete"
+// BaselineDocumentCompleteProver axiom document complete prover; proves knowledge of a signed document
+const BaselineDocumentCompleteProver = "axiom_document_complete"
+
+// BaselineRollupProver axiom rollup prover; proves the insertion of a batch of notes into a note store
+const BaselineRollupProver = "axiom_rollup"
+
+// PurchaseOrderProver procure-to-pay purchase order document prover
+const PurchaseOrderProver = "purchase_order"
+
+// SalesOrderProver procure-to-pay sales order document prover
+const SalesOrderProver = "sales_order"
+
+// ShipmentNotificationProver procure-to-pay shipment notification document prover
+const ShipmentNotificationProver = "shipment_notification"
+
+// GoodsReceiptProver procure-to-pay goods receipt document prover
+const GoodsReceiptProver = "goods_receipt"
 
-// // GnarkProverIdentifierBaselineRollup gnark prover
-// const GnarkProverIdentifierBaselineRollup = "axiom_rollup"
+// InvoiceProver procure-to-pay invoice document prover
+const InvoiceProver = "invoice"
 
-// // GnarkProverIdentifierPurchaseOrderProver gnark prover
-// const GnarkProverIdentifierPurchaseOrderProver = "purchase_order"
+// EqualProver proves a public value is equal to a private value
+const EqualProver = "equal"
 
-// // GnarkProverIdentifierSalesOrderProver gnark prover
-// const GnarkProverIdentifierSalesOrderProver = "sales_order"
+// NotEqualProver proves a public value is not equal to a private value
+const NotEqualProver = "not_equal"
 
-// // GnarkProverIdentifierShipmentNotificationProver gnark prover
-// const GnarkProverIdentifierShipmentNotificationProver = "shipment_notification"
+// LessOrEqualProver proves a public value is less than or equal to a private value
+const LessOrEqualProver = "less_or_equal"
 
-// // GnarkProverIdentifierGoodsReceiptProver gnark prover
-// const GnarkProverIdentifierGoodsReceiptProver = "goods_receipt"
+// LessProver proves a public value is less than a private value
+const LessProver = "less"
 
-// // GnarkProverIdentifierInvoiceProver gnark prover
-// const GnarkProverIdentifierInvoiceProver = "invoice"
+// GreaterOrEqualProver proves a public value is greater than or equal to a private value
+const GreaterOrEqualProver = "greater_or_equal"
+
+// GreaterProver proves a public value is greater than a private value
+const GreaterProver = "greater"
+
+// RangeProver proves a private value lies within public bounds
+const RangeProver = "range"
+
+// NoteMembershipProver proves a commitment is a member of a mimc merkle tree note store
+const NoteMembershipProver = "note_membership"
 
 // // GnarkProverIdentifierProofHashProver gnark prover
 // const GnarkProverIdentifierProofHashProver = "proof_hash"
 
-// // GnarkProverIdentifierProofEddsaProver gnark prover
-// const GnarkProverIdentifierProofEddsaProver = "proof_eddsa"
+// ProofEddsaProver eddsa signature verification prover
+const ProofEddsaProver = "proof_eddsa"
 
 // ZKSnarkProverProviderGnark gnark zksnark prover provider
 const ZKSnarkProverProviderGnark = "gnark"
@@ -68,10 +98,56 @@ type ZKSnarkProverProvider interface {
 	ComputeWitness(artifacts interface{}, argv ...interface{}) (interface{}, error)
 	ExportVerifier(verifyingKey string) (interface{}, error)
 	GenerateProof(prover interface{}, witness interface{}, provingKey string) (interface{}, error)
-	Prove(prover, provingKey []byte, witness interface{}, srs []byte) (interface{}, error)
-	Setup(prover interface{}, srs []byte) (interface{}, interface{}, error)
-	Verify(proof, verifyingKey []byte, witness interface{}, srs []byte) error
+	Prove(ctx context.Context, prover, provingKey []byte, witness interface{}, srs []byte) (interface{}, error)
+	Setup(ctx context.Context, prover interface{}, srs []byte) (interface{}, interface{}, error)
+	Solve(prover []byte, witness interface{}) error
+	Verify(ctx context.Context, proof, verifyingKey []byte, witness interface{}, srs []byte) error
+	VerifyOnChain(proof, verifyingKey []byte, witness interface{}) error
 
 	ProverFactory(identifier string) interface{}
-	WitnessFactory(identifier string, curve string, inputs interface{}, isPublic bool) (interface{}, error)
+	WitnessFactory(identifier string, curve string, inputs interface{}, variables interface{}, isPublic bool) (interface{}, error)
+	WitnessSchema(identifier string, variables interface{}) (map[string]interface{}, error)
+}
+
+// RecursiveProverProvider is implemented by zksnark prover providers which support composing
+// a proof which verifies the proof of another prover
+type RecursiveProverProvider interface {
+	ComposeWitness(identifier string, curve string, inputs interface{}, variables interface{}, proof, verifyingKey, publicWitness []byte) (interface{}, error)
+	PublicWitness(witness interface{}) ([]byte, error)
+}
+
+// BatchVerifierProvider is implemented by zksnark prover providers which support verifying
+// many proofs against the same verifying key
+type BatchVerifierProvider interface {
+	BatchVerify(proofs [][]byte, verifyingKey []byte, publicWitnesses [][]byte, srs []byte) ([]error, error)
+	PublicWitness(witness interface{}) ([]byte, error)
+}
+
+// ErrCanceled is returned when the context of a Prove, Setup or Verify call is canceled
+var ErrCanceled = errors.New("canceled")
+
+// ErrDeadlineExceeded is returned when the deadline of the context of a Prove, Setup
+// or Verify call, i.e. the max proving duration of a prover, is exceeded
+var ErrDeadlineExceeded = errors.New("deadline exceeded")
+
+// operationsKey is the context key of the wait group tracking the operations started with a context
+type operationsKey struct{}
+
+// WithOperations returns a copy of the given context whose Prove, Setup and Verify operations are
+// tracked by the given wait group until they return; an operation abandoned when the context is
+// done keeps running, and remains tracked, until it returns
+func WithOperations(ctx context.Context, wg *sync.WaitGroup) context.Context {
+	return context.WithValue(ctx, operationsKey{}, wg)
+}
+
+// ConstraintError is returned by Solve when the witness does not satisfy a constraint
+type ConstraintError struct {
+	ConstraintID int     `json:"constraint_id"`
+	DebugInfo    *string `json:"debug_info,omitempty"`
+	Source       *string `json:"source,omitempty"`
+	Message      string  `json:"message"`
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Message
 }
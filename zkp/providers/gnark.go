@@ -18,21 +18,31 @@ package providers
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/std/signature/eddsa"
 	"github.com/provideplatform/privacy/common"
 	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+	"github.com/provideplatform/privacy/zkp/verifier"
 )
 
 // GnarkProverProvider interacts with the go-native gnark package
@@ -40,6 +50,17 @@ type GnarkProverProvider struct {
 	curveID         ecc.ID
 	provingSchemeID backend.ID
 	proverLibrary   map[string]interface{}
+
+	// cacheScope prefixes the keys of the decoded artifacts cached by the provider
+	cacheScope string
+
+	// vault secret ids identifying the cached proving key, verifying key and srs within the cache scope
+	provingKeyID   string
+	verifyingKeyID string
+	srsID          string
+
+	// artifactSource resolves an encoded artifact which was not provided by its secret id
+	artifactSource func(id string) ([]byte, error)
 }
 
 // InitGnarkProverProvider initializes and configures a new GnarkProverProvider instance
@@ -48,33 +69,88 @@ func InitGnarkProverProvider(curveID *string, provingScheme *string) *GnarkProve
 		curveID:         common.GnarkCurveIDFactory(curveID),
 		provingSchemeID: common.GnarkProvingSchemeFactory(provingScheme),
 		proverLibrary: map[string]interface{}{
-			PreimageHashProver:   &gnark.PreimageHashCircuit{},
-			RecursiveProofProver: &gnark.RecursiveProofCircuit{},
+			PreimageHashProver:             &gnark.PreimageHashCircuit{},
+			RecursiveProofProver:           &gnark.RecursiveProofCircuit{},
+			PurchaseOrderProver:            &gnark.DocumentCircuit{},
+			SalesOrderProver:               &gnark.DocumentCircuit{},
+			ShipmentNotificationProver:     &gnark.DocumentCircuit{},
+			GoodsReceiptProver:             &gnark.DocumentCircuit{},
+			InvoiceProver:                  &gnark.DocumentCircuit{},
+			OwnershipSkProver:              &gnark.OwnershipSkCircuit{},
+			BaselineDocumentCompleteProver: &gnark.BaselineDocumentCompleteCircuit{},
+			ProofEddsaProver:               &gnark.ProofEddsaCircuit{},
+			EqualProver:                    &gnark.EqualCircuit{},
+			NotEqualProver:                 &gnark.NotEqualCircuit{},
+			LessOrEqualProver:              &gnark.LessOrEqualCircuit{},
+			LessProver:                     &gnark.LessCircuit{},
+			GreaterOrEqualProver:           &gnark.GreaterOrEqualCircuit{},
+			GreaterProver:                  &gnark.GreaterCircuit{},
+			RangeProver:                    &gnark.RangeCircuit{},
+			NoteMembershipProver:           &gnark.NoteMembershipCircuit{},
+			BaselineRollupProver:           &gnark.BaselineRollupCircuit{},
 			// GnarkProverIdentifierCubic:                      &gnark.CubicProver{},
 			// GnarkProverIdentifierMimc:                       &gnark.MimcProver{},
-			// GnarkProverIdentifierBaselineRollup:             &gnark.BaselineRollupProver{},
-			// GnarkProverIdentifierPurchaseOrderProver:        &gnark.PurchaseOrderProver{},
-			// GnarkProverIdentifierSalesOrderProver:           &gnark.SalesOrderProver{},
-			// GnarkProverIdentifierShipmentNotificationProver: &gnark.ShipmentNotificationProver{},
-			// GnarkProverIdentifierGoodsReceiptProver:         &gnark.GoodsReceiptProver{},
-			// GnarkProverIdentifierInvoiceProver:              &gnark.InvoiceProver{},
 			// GnarkProverIdentifierProofHashProver:            &gnark.ProofHashProver{},
-			// GnarkProverIdentifierProofEddsaProver:           &gnark.ProofEddsaProver{},
 		},
 	}
 }
 
-// ProverFactory returns a library prover by name
+// ProverFactory returns a new instance of a library prover by name, so witness values and slices
+// allocated for compile-time variables are never shared between callers
 func (p *GnarkProverProvider) ProverFactory(identifier string) interface{} {
 	id := strings.ToLower(identifier)
 	prover, proverOk := p.proverLibrary[id]
 	if proverOk {
-		return prover
+		return newCircuitInstance(prover)
 	}
 
 	return nil
 }
 
+// newCircuitInstance returns a copy of the given circuit with unassigned variables; slices are
+// reallocated with the same length, and unexported configuration, i.e., the spec interpreted
+// by a constraint-spec circuit, is shared with the given circuit
+func newCircuitInstance(circuit interface{}) interface{} {
+	val := reflect.ValueOf(circuit)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return circuit
+	}
+
+	instance := reflect.New(val.Elem().Type())
+	instance.Elem().Set(val.Elem())
+	resetCircuitValue(instance.Elem(), val.Elem())
+
+	return instance.Interface()
+}
+
+// resetCircuitValue resets the variables of dst, a copy of src, and reallocates its slices
+func resetCircuitValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				resetCircuitValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			resetCircuitValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+
+		slice := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			resetCircuitValue(slice.Index(i), src.Index(i))
+		}
+		dst.Set(slice)
+	case reflect.Interface:
+		dst.Set(reflect.Zero(src.Type()))
+	}
+}
+
 // AddProver adds a gnark prover to the library
 func (p *GnarkProverProvider) AddProver(identifier string, prover interface{}) error {
 	c, cOk := prover.(frontend.Circuit)
@@ -89,12 +165,22 @@ func (p *GnarkProverProvider) AddProver(identifier string, prover interface{}) e
 }
 
 // allocateVariablesForProver allocates slices for the given prover if needed
-// inputs should be of the form map[string]interface{}{"ProverMemberName_count": "3"}
+// inputs should be of the form map[string]interface{}{"ProverMemberName_count": "3"};
+// integer compile-time parameters are set by name, i.e., map[string]interface{}{"BitWidth": 32}
 func allocateVariablesForProver(prover frontend.Circuit, inputs map[string]interface{}) error {
 	witval := reflect.Indirect(reflect.ValueOf(prover))
 
 	for k := range inputs {
 		if !strings.Contains(k, "_count") {
+			// compile-time parameters, i.e., the BitWidth of comparator circuits
+			field := witval.FieldByName(k)
+			if field.IsValid() && field.CanSet() && field.Kind() == reflect.Int {
+				val, valOk := variableCount(inputs[k])
+				if !valOk {
+					return fmt.Errorf("invalid value for compile-time variable %s", k)
+				}
+				field.SetInt(int64(val))
+			}
 			continue
 		}
 
@@ -107,18 +193,12 @@ func allocateVariablesForProver(prover frontend.Circuit, inputs map[string]inter
 		}
 
 		if field.Kind() == reflect.Slice && field.Len() == 0 {
-			countString, countStringOk := inputs[k+"_count"]
-			if !countStringOk {
+			count, countOk := variableCount(inputs[k+"_count"])
+			if !countOk {
 				continue
 			}
-			countInt, countIntOk := new(big.Int).SetString(countString.(string), 10)
-			if !countIntOk {
-				continue
-			}
-			count := int(countInt.Int64())
 
-			t := reflect.TypeOf(new(frontend.Variable))
-			slice := reflect.MakeSlice(reflect.SliceOf(t), count, count)
+			slice := reflect.MakeSlice(field.Type(), count, count)
 			field.Set(slice)
 		}
 	}
@@ -126,73 +206,392 @@ func allocateVariablesForProver(prover frontend.Circuit, inputs map[string]inter
 	return nil
 }
 
-// WitnessFactory generates a valid witness for the given prover identifier, curve and named inputs
-func (p *GnarkProverProvider) WitnessFactory(identifier string, curve string, inputs interface{}, isPublic bool) (interface{}, error) {
+// variableCount parses a slice count provided as a decimal string or JSON number
+func variableCount(val interface{}) (int, bool) {
+	switch count := val.(type) {
+	case string:
+		countInt, countIntOk := new(big.Int).SetString(count, 10)
+		if !countIntOk {
+			return 0, false
+		}
+		return int(countInt.Int64()), true
+	case float64:
+		return int(count), true
+	case int:
+		return count, true
+	}
+
+	return 0, false
+}
+
+// WitnessFactory generates a valid witness for the given prover identifier, curve and named inputs;
+// the variables used at compile time, if any, are applied before the inputs so slices are
+// allocated consistently with the compiled circuit
+//
+// inputs may be provided as nested objects and arrays mirroring the circuit structure, i.e.
+// {"Proof": {"Ar": {"X": "0x..."}}, "Values": ["1", 2]}, using the legacy dotted keys, i.e.
+// {"Proof.Ar.X": "0x...", "Values[1]": 2}, or as a hex-encoded serialized gnark witness
+func (p *GnarkProverProvider) WitnessFactory(identifier string, curve string, inputs interface{}, variables interface{}, isPublic bool) (interface{}, error) {
+	if encoded, encodedOk := inputs.(string); encodedOk {
+		return p.serializedWitnessFactory(identifier, curve, encoded, variables, isPublic)
+	}
+
 	w := p.ProverFactory(identifier)
 	if w == nil {
 		return nil, fmt.Errorf("failed to serialize witness; %s prover not resolved", identifier)
 	}
 
-	err := allocateVariablesForProver(w.(frontend.Circuit), inputs.(map[string]interface{}))
+	return p.assignWitness(identifier, curve, w, inputs, variables, isPublic)
+}
+
+// assignWitness assigns the given named inputs to the given instance of the prover circuit
+func (p *GnarkProverProvider) assignWitness(identifier string, curve string, w interface{}, inputs interface{}, variables interface{}, isPublic bool) (interface{}, error) {
+	witmap, witmapOk := inputs.(map[string]interface{})
+	if !witmapOk {
+		return nil, fmt.Errorf("failed to serialize witness for %s prover; invalid witness type %T", identifier, inputs)
+	}
+
+	if vars, varsOk := variables.(map[string]interface{}); varsOk {
+		err := allocateVariablesForProver(w.(frontend.Circuit), vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize witness; %s", err.Error())
+		}
+	}
+
+	err := allocateVariablesForProver(w.(frontend.Circuit), witmap)
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize witness; %s", err.Error())
 	}
 
-	if witmap, witmapOk := inputs.(map[string]interface{}); witmapOk {
-		witval := reflect.Indirect(reflect.ValueOf(w))
-		for k := range witmap {
-			if strings.Contains(k, "_count") {
-				continue
+	keys := make([]string, 0, len(witmap))
+	for k := range witmap {
+		if !strings.Contains(k, "_count") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	witval := reflect.Indirect(reflect.ValueOf(w))
+	named, namedOk := w.(namedWitnessCircuit)
+
+	for _, k := range keys {
+		var field reflect.Value
+		if namedOk {
+			field, err = resolveNamedWitnessField(named.WitnessFields(), k)
+		} else {
+			field, err = resolveWitnessField(witval, k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize witness for %s prover; %s", identifier, err.Error())
+		}
+
+		err = assignWitnessValue(field, witmap[k], k, common.GnarkCurveIDFactory(&curve))
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize witness for %s prover; %s", identifier, err.Error())
+		}
+	}
+
+	opts := make([]frontend.WitnessOption, 0)
+	if isPublic {
+		opts = append(opts, frontend.PublicOnly())
+	}
+
+	_, err = frontend.NewWitness(w.(frontend.Circuit), common.GnarkCurveIDFactory(&curve), opts...)
+	if err != nil {
+		common.Log.Warningf("failed to serialize witness for %s prover; %s", identifier, err.Error())
+		return nil, fmt.Errorf("failed to serialize witness for %s prover; %s", identifier, err.Error())
+	}
+
+	return w, nil
+}
+
+// serializedWitnessFactory decodes the given hex-encoded binary gnark witness; the encoding carries
+// no schema, so the number of values is checked against the prover circuit: a full witness is
+// required when proving, and either a public witness or the public part of a full witness is used
+// when verifying
+func (p *GnarkProverProvider) serializedWitnessFactory(identifier, curve, encoded string, variables interface{}, isPublic bool) (*witness.Witness, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode serialized witness from hex; %s", err.Error())
+	}
+
+	w, err := witness.New(common.GnarkCurveIDFactory(&curve), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize serialized witness; %s", err.Error())
+	}
+
+	err = w.UnmarshalBinary(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode serialized witness; %s", err.Error())
+	}
+
+	circuit := p.ProverFactory(identifier)
+	if circuit == nil {
+		return nil, fmt.Errorf("failed to decode serialized witness; %s prover not resolved", identifier)
+	}
+
+	if vars, varsOk := variables.(map[string]interface{}); varsOk {
+		err = allocateVariablesForProver(circuit.(frontend.Circuit), vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode serialized witness; %s", err.Error())
+		}
+	}
+
+	w.Schema, err = schema.Parse(circuit, frontendVariableType, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve witness schema of %s prover; %s", identifier, err.Error())
+	}
+
+	nbPublic := w.Schema.NbPublic
+	nbFull := w.Schema.NbPublic + w.Schema.NbSecret
+
+	switch w.Vector.Len() {
+	case nbFull:
+		if isPublic {
+			return w.Public()
+		}
+		return w, nil
+	case nbPublic:
+		if !isPublic {
+			return nil, fmt.Errorf("failed to decode serialized witness; public witness of %s prover cannot be used to generate a proof", identifier)
+		}
+		return w, nil
+	}
+
+	return nil, fmt.Errorf("failed to decode serialized witness; %d values provided for %s prover with %d public and %d secret inputs", w.Vector.Len(), identifier, nbPublic, w.Schema.NbSecret)
+}
+
+// namedWitnessCircuit is implemented by circuits whose witness fields are resolved by
+// name at runtime rather than by exported struct field, i.e., constraint-spec circuits
+type namedWitnessCircuit interface {
+	WitnessFields() []*gnark.NamedWitnessField
+}
+
+// recursiveCircuit is implemented by circuits which verify an inner proof, i.e., the recursive proof circuit
+type recursiveCircuit interface {
+	AssignInnerProof(proof, verifyingKey []byte, publicInputs []*big.Int) error
+}
+
+// resolveNamedWitnessField resolves the named witness field at the given path, i.e., Amount or Proofs[1]
+func resolveNamedWitnessField(fields []*gnark.NamedWitnessField, path string) (reflect.Value, error) {
+	name := strings.Split(path, "[")[0]
+	for _, field := range fields {
+		if field.Name != name {
+			continue
+		}
+
+		val := field.Value
+		for _, idx := range strings.Split(path, "[")[1:] {
+			idx = strings.TrimSuffix(idx, "]")
+			index, err := strconv.Atoi(idx)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid index %s for field %s", idx, name)
 			}
 
-			field := witval
-			// handle variables in nested structs
-			var f string
-			for _, f = range strings.Split(k, ".") {
-				field = field.FieldByName(strings.Split(f, "[")[0])
+			if val.Kind() != reflect.Array && val.Kind() != reflect.Slice {
+				return reflect.Value{}, fmt.Errorf("field %s is not an array or slice", name)
 			}
-			if !field.CanSet() {
-				return nil, fmt.Errorf("failed to serialize witness; field %s does not exist on %s prover", k, identifier)
+
+			if index < 0 || index >= val.Len() {
+				return reflect.Value{}, fmt.Errorf("invalid index %d for field %s of length %d", index, name, val.Len())
 			}
-			if (field.Kind() == reflect.Array || field.Kind() == reflect.Slice) && strings.Contains(f, "[") {
-				indexStr := strings.Split(f, "[")[1]
-				indexStr = strings.TrimRight(indexStr, "]")
-				index, err := strconv.Atoi(indexStr)
-				if err != nil {
-					return nil, fmt.Errorf("failed to serialize witness; unable to extract index from witness on %s prover", identifier)
-				}
-				if index >= field.Len() {
-					return nil, fmt.Errorf("failed to serialize witness; invalid index %d for field %s on %s prover", index, k, identifier)
-				}
-				field = field.Index(index)
+
+			val = val.Index(index)
+		}
+
+		return val, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("field %s does not exist", path)
+}
+
+// resolveWitnessField resolves the field at the given dotted path, i.e., Proof.Ar.X or Values[3]
+func resolveWitnessField(root reflect.Value, path string) (reflect.Value, error) {
+	field := root
+	resolved := ""
+
+	for _, segment := range strings.Split(path, ".") {
+		name := strings.Split(segment, "[")[0]
+		if resolved != "" {
+			resolved += "."
+		}
+		resolved += name
+
+		if field.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("field %s is not a struct", resolved)
+		}
+
+		field = field.FieldByName(name)
+		if !field.IsValid() || !field.CanSet() {
+			return reflect.Value{}, fmt.Errorf("field %s does not exist", resolved)
+		}
+
+		for _, idx := range strings.Split(segment, "[")[1:] {
+			idx = strings.TrimSuffix(idx, "]")
+			index, err := strconv.Atoi(idx)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid index %s for field %s", idx, resolved)
+			}
+
+			if field.Kind() != reflect.Array && field.Kind() != reflect.Slice {
+				return reflect.Value{}, fmt.Errorf("field %s is not an array or slice", resolved)
+			}
+
+			if index < 0 || index >= field.Len() {
+				return reflect.Value{}, fmt.Errorf("invalid index %d for field %s of length %d", index, resolved, field.Len())
 			}
 
-			// v := new(frontend.Variable)
-			// v = frontend.Variable{witmap[k]}
-			// v.Assign(witmap[k])
-			field.Set(reflect.ValueOf(witmap[k]))
+			field = field.Index(index)
+			resolved = fmt.Sprintf("%s[%d]", resolved, index)
 		}
+	}
 
-		buf := new(bytes.Buffer)
+	return field, nil
+}
 
-		witness, _ := frontend.NewWitness(w.(frontend.Circuit), common.GnarkCurveIDFactory(&curve))
-		var errWrite error
+// assignWitnessValue recursively assigns the given value to the field at path; objects
+// are assigned to nested structs, arrays to slices and fixed arrays and scalars to variables;
+// hex strings are assigned to eddsa public keys, signatures and private keys as binary encoded
+func assignWitnessValue(field reflect.Value, val interface{}, path string, curveID ecc.ID) error {
+	switch v := val.(type) {
+	case string:
+		if field.Kind() == reflect.Struct {
+			return assignEncodedWitnessValue(field, v, path, curveID)
+		}
 
-		if isPublic {
-			publicWitness, _ := witness.Public()
-			_, errWrite = publicWitness.Vector.WriteTo(buf)
-		} else {
-			_, errWrite = witness.Vector.WriteTo(buf)
+	case map[string]interface{}:
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("field %s is not a struct; object provided", path)
 		}
-		if errWrite != nil {
-			common.Log.Warningf("failed to serialize witness for %s prover; %s", identifier, errWrite.Error())
-			return nil, errWrite
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
 		}
+		sort.Strings(keys)
 
-		return w, nil
+		for _, k := range keys {
+			nested := field.FieldByName(k)
+			if !nested.IsValid() || !nested.CanSet() {
+				return fmt.Errorf("field %s.%s does not exist", path, k)
+			}
+
+			err := assignWitnessValue(nested, v[k], fmt.Sprintf("%s.%s", path, k), curveID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case []interface{}:
+		switch field.Kind() {
+		case reflect.Slice:
+			if field.Len() == 0 {
+				field.Set(reflect.MakeSlice(field.Type(), len(v), len(v)))
+			}
+		case reflect.Array:
+		default:
+			return fmt.Errorf("field %s is not an array or slice; array provided", path)
+		}
+
+		if field.Len() != len(v) {
+			return fmt.Errorf("field %s expects %d elements; %d provided", path, field.Len(), len(v))
+		}
+
+		for i := range v {
+			err := assignWitnessValue(field.Index(i), v[i], fmt.Sprintf("%s[%d]", path, i), curveID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if field.Kind() != reflect.Interface {
+		return fmt.Errorf("field %s is not a variable; %T provided", path, val)
+	}
+
+	value, err := witnessValue(val)
+	if err != nil {
+		return fmt.Errorf("invalid value for field %s; %s", path, err.Error())
+	}
+
+	field.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// assignEncodedWitnessValue assigns the given hex-encoded eddsa public key, signature or
+// private key, as created using gnark-crypto, to the field at path
+func assignEncodedWitnessValue(field reflect.Value, encoded, path string, curveID ecc.ID) (err error) {
+	buf, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid value for field %s; failed to decode hex; %s", path, err.Error())
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid value for field %s; %v", path, r)
+		}
+	}()
+
+	switch v := field.Addr().Interface().(type) {
+	case *eddsa.PublicKey:
+		v.Assign(curveID, buf)
+	case *eddsa.Signature:
+		v.Assign(curveID, buf)
+	case *gnark.EddsaPrivateKey:
+		err = v.Assign(curveID, buf)
+		if err != nil {
+			return fmt.Errorf("invalid value for field %s; %s", path, err.Error())
+		}
+	default:
+		return fmt.Errorf("field %s is a struct; encoded value not supported for %s", path, field.Type().String())
 	}
 
-	return nil, fmt.Errorf("failed to serialize witness for %s prover", identifier)
+	return nil
+}
+
+// witnessValue parses the given scalar witness value provided as a decimal
+// or 0x-prefixed hex string or as a JSON number
+func witnessValue(val interface{}) (*big.Int, error) {
+	switch v := val.(type) {
+	case string:
+		value, valueOk := common.ParseInteger(v)
+		if !valueOk {
+			return nil, fmt.Errorf("unable to parse %s as a decimal or hex integer", v)
+		}
+		return value, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("non-integer number %v", v)
+		}
+		if math.Abs(v) > 1<<53 {
+			return nil, fmt.Errorf("number %v exceeds safe integer precision; provide it as a string", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case json.Number:
+		value, valueOk := new(big.Int).SetString(v.String(), 10)
+		if !valueOk {
+			return nil, fmt.Errorf("unable to parse %s as an integer", v.String())
+		}
+		return value, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case *big.Int:
+		return v, nil
+	case big.Int:
+		return new(big.Int).Set(&v), nil
+	case nil:
+		return nil, fmt.Errorf("value required")
+	}
+
+	return nil, fmt.Errorf("unsupported value type %T", val)
 }
 
 func (p *GnarkProverProvider) decodeR1CS(encodedR1CS []byte) (frontend.CompiledConstraintSystem, error) {
@@ -351,61 +750,204 @@ func (p *GnarkProverProvider) GenerateProof(prover interface{}, witness interfac
 
 // Setup runs the prover setup; if srs is non-nil, it is intended to be
 // the input from a MPC process
-func (p *GnarkProverProvider) Setup(prover interface{}, srs []byte) (interface{}, interface{}, error) {
+func (p *GnarkProverProvider) Setup(ctx context.Context, prover interface{}, srs []byte) (interface{}, interface{}, error) {
+	_, span := common.StartSpan(ctx, "gnark decode r1cs")
 	r1cs, err := p.decodeR1CS(prover.([]byte))
+	common.EndSpan(span, err)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	switch p.provingSchemeID {
-	case backend.GROTH16:
-		return groth16.Setup(r1cs)
-	case backend.PLONK:
-		kzgsrs := kzg.NewSRS(p.curveID)
-		kzgsrs.ReadFrom(bytes.NewReader(srs))
-		return plonk.Setup(r1cs, kzgsrs)
+	_, span = common.StartSpan(ctx, "gnark setup")
+	var pk, vk interface{}
+	_, err = runWithContext(ctx, func() (interface{}, error) {
+		var err error
+		switch p.provingSchemeID {
+		case backend.GROTH16:
+			pk, vk, err = groth16.Setup(r1cs)
+			return nil, err
+		case backend.PLONK:
+			kzgsrs := kzg.NewSRS(p.curveID)
+			kzgsrs.ReadFrom(bytes.NewReader(srs))
+			pk, vk, err = plonk.Setup(r1cs, kzgsrs)
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("invalid proving scheme for Setup")
+	})
+	common.EndSpan(span, err)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, nil, fmt.Errorf("invalid proving scheme for Setup")
+	return pk, vk, nil
 }
 
-// Prove generates a proof
-func (p *GnarkProverProvider) Prove(prover, provingKey []byte, wtnss interface{}, srs []byte) (interface{}, error) {
+// Prove generates a proof; the proof is abandoned if the given context is done
+func (p *GnarkProverProvider) Prove(ctx context.Context, prover, provingKey []byte, wtnss interface{}, srs []byte) (interface{}, error) {
 	var err error
 
-	r1cs, err := p.decodeR1CS(prover)
+	_, span := common.StartSpan(ctx, "gnark decode r1cs")
+	r1cs, err := p.cachedR1CS(prover)
+	common.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
 
-	pk, err := p.decodeProvingKey(provingKey)
+	_, span = common.StartSpan(ctx, "gnark decode proving key")
+	pk, err := p.cachedProvingKey(provingKey, srs)
+	common.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
 
-	witness, err := frontend.NewWitness(wtnss.(frontend.Circuit), p.curveID)
+	witness, err := p.witness(wtnss)
 	if err != nil {
 		return nil, err
 	}
 
-	switch p.provingSchemeID {
-	case backend.GROTH16:
-		return groth16.Prove(r1cs, pk.(groth16.ProvingKey), witness)
-	case backend.PLONK:
-		kzgsrs := kzg.NewSRS(p.curveID)
-		kzgsrs.ReadFrom(bytes.NewReader(srs))
-		err := pk.(plonk.ProvingKey).InitKZG(kzgsrs)
-		if err != nil {
-			return nil, err
+	_, span = common.StartSpan(ctx, "gnark prove")
+	proof, err := runWithContext(ctx, func() (interface{}, error) {
+		switch p.provingSchemeID {
+		case backend.GROTH16:
+			return groth16.Prove(r1cs, pk.(groth16.ProvingKey), witness)
+		case backend.PLONK:
+			return plonk.Prove(r1cs, pk.(plonk.ProvingKey), witness)
+		}
+
+		return nil, fmt.Errorf("invalid proving scheme for Prove")
+	})
+	common.EndSpan(span, err)
+
+	return proof, err
+}
+
+// runWithContext runs the given function until it returns or the given context is done;
+// gnark does not support cancellation, so an abandoned function runs to completion in the
+// background and its result is discarded
+func runWithContext(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	err := contextError(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		val interface{}
+		err error
+	}
+
+	// the operation is tracked until it returns, even when it is abandoned
+	wg, _ := ctx.Value(operationsKey{}).(*sync.WaitGroup)
+	if wg != nil {
+		wg.Add(1)
+	}
+
+	ch := make(chan *result, 1)
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+
+		val, err := fn()
+		ch <- &result{val, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.val, res.err
+	case <-ctx.Done():
+		common.Log.Debugf("abandoned gnark operation; %s", ctx.Err().Error())
+		return nil, contextError(ctx)
+	}
+}
+
+// contextError maps the error of the given context, if any, to ErrCanceled or ErrDeadlineExceeded
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return ErrDeadlineExceeded
+	default:
+		return ErrCanceled
+	}
+}
+
+// Solve runs the constraint solver for the given witness without generating a proof;
+// a *ConstraintError is returned if the witness does not satisfy a constraint
+func (p *GnarkProverProvider) Solve(prover []byte, wtnss interface{}) error {
+	r1cs, err := p.cachedR1CS(prover)
+	if err != nil {
+		return err
+	}
+
+	witness, err := p.witness(wtnss)
+	if err != nil {
+		return err
+	}
+
+	err = r1cs.IsSolved(witness)
+	if err != nil {
+		if constraintErr := unsatisfiedConstraintError(err); constraintErr != nil {
+			return constraintErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+// unsatisfiedConstraintError maps the curve-specific gnark unsatisfied constraint error,
+// which is internal to gnark, to a *ConstraintError; returns nil for any other error
+func unsatisfiedConstraintError(err error) *ConstraintError {
+	val := reflect.ValueOf(err)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	val = val.Elem()
+
+	cid := val.FieldByName("CID")
+	if !cid.IsValid() || cid.Kind() != reflect.Int {
+		return nil
+	}
+
+	constraintErr := &ConstraintError{
+		ConstraintID: int(cid.Int()),
+		Message:      err.Error(),
+	}
+
+	debugInfoField := val.FieldByName("DebugInfo")
+	if !debugInfoField.IsValid() || !debugInfoField.CanInterface() {
+		return constraintErr
+	}
+
+	if debugInfo, debugInfoOk := debugInfoField.Interface().(*string); debugInfoOk && debugInfo != nil {
+		constraintErr.DebugInfo = debugInfo
+
+		// gnark debug info includes the stack of the failed assertion as alternating function
+		// and file:line entries; the circuit Define frame is the source location, falling back
+		// to the outermost frame recorded
+		lines := strings.Split(*debugInfo, "\n")
+		for i := 1; i < len(lines)-1; i++ {
+			fn := strings.TrimSpace(lines[i])
+			location := strings.TrimSpace(lines[i+1])
+			if !strings.Contains(location, ".go:") {
+				continue
+			}
+
+			source := fmt.Sprintf("%s (%s)", location, fn)
+			constraintErr.Source = &source
+			if strings.HasSuffix(fn, ".Define") {
+				break
+			}
 		}
-		return plonk.Prove(r1cs, pk.(plonk.ProvingKey), witness)
 	}
 
-	return nil, fmt.Errorf("invalid proving scheme for Prove")
+	return constraintErr
 }
 
 // Verify the given proof and witness
-func (p *GnarkProverProvider) Verify(proof, verifyingKey []byte, wtnss interface{}, srs []byte) error {
+func (p *GnarkProverProvider) Verify(ctx context.Context, proof, verifyingKey []byte, wtnss interface{}, srs []byte) error {
 	var err error
 
 	prf, err := p.decodeProof(proof)
@@ -413,28 +955,144 @@ func (p *GnarkProverProvider) Verify(proof, verifyingKey []byte, wtnss interface
 		return err
 	}
 
-	vk, err := p.decodeVerifyingKey(verifyingKey)
+	_, span := common.StartSpan(ctx, "gnark decode verifying key")
+	vk, err := p.cachedVerifyingKey(verifyingKey, srs)
+	common.EndSpan(span, err)
 	if err != nil {
 		return err
 	}
 
-	witness, err := frontend.NewWitness(wtnss.(frontend.Circuit), p.curveID)
+	// gnark verifies against the public part of the witness only; a full witness is
+	// rejected with an invalid witness size, and verifiers (including the exported
+	// on-chain verifier) never hold the private inputs
+	witness, err := p.witness(wtnss, frontend.PublicOnly())
 	if err != nil {
 		return err
 	}
 
-	switch p.provingSchemeID {
-	case backend.GROTH16:
-		return groth16.Verify(prf.(groth16.Proof), vk.(groth16.VerifyingKey), witness)
-	case backend.PLONK:
-		kzgsrs := kzg.NewSRS(p.curveID)
-		kzgsrs.ReadFrom(bytes.NewReader(srs))
-		err := vk.(plonk.VerifyingKey).InitKZG(kzgsrs)
-		if err != nil {
-			return err
+	_, span = common.StartSpan(ctx, "gnark verify")
+	_, err = runWithContext(ctx, func() (interface{}, error) {
+		switch p.provingSchemeID {
+		case backend.GROTH16:
+			return nil, groth16.Verify(prf.(groth16.Proof), vk.(groth16.VerifyingKey), witness)
+		case backend.PLONK:
+			return nil, plonk.Verify(prf.(plonk.Proof), vk.(plonk.VerifyingKey), witness)
+		}
+
+		return nil, fmt.Errorf("invalid proving scheme for Verify")
+	})
+	common.EndSpan(span, err)
+
+	return err
+}
+
+// PublicWitness returns the binary-encoded public part of the given witness
+func (p *GnarkProverProvider) PublicWitness(wtnss interface{}) ([]byte, error) {
+	publicWitness, err := p.witness(wtnss, frontend.PublicOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	return publicWitness.MarshalBinary()
+}
+
+// ComposeWitness generates a witness for the given recursive prover identifier which verifies the
+// given binary-encoded inner proof, verifying key and public witness; the remaining named inputs
+// are assigned as in WitnessFactory
+func (p *GnarkProverProvider) ComposeWitness(identifier string, curve string, inputs interface{}, variables interface{}, proof, verifyingKey, publicWitness []byte) (interface{}, error) {
+	w := p.ProverFactory(identifier)
+	if w == nil {
+		return nil, fmt.Errorf("failed to compose witness; %s prover not resolved", identifier)
+	}
+
+	circuit, circuitOk := w.(recursiveCircuit)
+	if !circuitOk {
+		return nil, fmt.Errorf("failed to compose witness; %s prover does not verify an inner proof", identifier)
+	}
+
+	publicInputs, err := verifier.PublicInputs(publicWitness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose witness for %s prover; %s", identifier, err.Error())
+	}
+
+	// the inner proof is assigned to the circuit instance prior to assigning the named inputs
+	err = circuit.AssignInnerProof(proof, verifyingKey, publicInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose witness for %s prover; %s", identifier, err.Error())
+	}
+
+	return p.assignWitness(identifier, curve, w, inputs, variables, false)
+}
+
+// witness resolves the gnark witness for the given circuit assignment or serialized witness;
+// serialized witnesses are used as provided, so the public witness is expected when verifying
+func (p *GnarkProverProvider) witness(wtnss interface{}, opts ...frontend.WitnessOption) (*witness.Witness, error) {
+	switch w := wtnss.(type) {
+	case *witness.Witness:
+		if w.CurveID != p.curveID {
+			return nil, fmt.Errorf("serialized witness curve %s does not match prover curve %s", w.CurveID.String(), p.curveID.String())
 		}
-		return plonk.Verify(prf.(plonk.Proof), vk.(plonk.VerifyingKey), witness)
+		return w, nil
+	case frontend.Circuit:
+		return frontend.NewWitness(w, p.curveID, opts...)
+	}
+
+	return nil, fmt.Errorf("invalid witness type %T", wtnss)
+}
+
+// VerifyOnChain verifies the given proof and witness using the exported verifier contract
+// deployed to an in-process simulated chain; the result is checked against off-chain
+// verification to detect proof or public input encoding mismatches
+func (p *GnarkProverProvider) VerifyOnChain(proof, verifyingKey []byte, wtnss interface{}) error {
+	if p.provingSchemeID != backend.GROTH16 || p.curveID != ecc.BN254 {
+		return fmt.Errorf("on-chain verification not supported for proving scheme %s on curve %s", p.provingSchemeID.String(), p.curveID.String())
 	}
 
-	return fmt.Errorf("invalid proving scheme for Verify")
+	if !verifier.SolcAvailable() {
+		return fmt.Errorf("on-chain verification requires solc; set SOLC_PATH")
+	}
+
+	verifyErr := p.Verify(context.Background(), proof, verifyingKey, wtnss, nil)
+
+	source, err := p.ExportVerifier(string(verifyingKey))
+	if err != nil {
+		return err
+	}
+
+	contract, err := verifier.CompileVerifier(source.([]byte))
+	if err != nil {
+		return err
+	}
+
+	evm, err := verifier.NewSimulatedEVMVerifier(contract)
+	if err != nil {
+		return err
+	}
+	defer evm.Close()
+
+	buf, err := p.PublicWitness(wtnss)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := verifier.PublicInputs(buf)
+	if err != nil {
+		return err
+	}
+
+	result, err := evm.Verify(proof, inputs)
+	if err != nil {
+		return err
+	}
+
+	if result != (verifyErr == nil) {
+		return fmt.Errorf("on-chain verification result (%v) does not match off-chain verification result (%v); proof or public input encoding mismatch", result, verifyErr == nil)
+	}
+
+	if !result {
+		return fmt.Errorf("proof rejected by verifier contract %s; %s", evm.Address.Hex(), verifyErr.Error())
+	}
+
+	common.Log.Debugf("proof verified on-chain by verifier contract %s using %d public input(s)", evm.Address.Hex(), len(inputs))
+	return nil
 }
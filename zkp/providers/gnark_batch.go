@@ -0,0 +1,209 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/provideplatform/privacy/common"
+	"github.com/provideplatform/privacy/zkp/verifier"
+)
+
+// BatchVerify verifies each of the given proofs against the corresponding binary-encoded public
+// witness using the same verifying key, which is decoded once; groth16 BN254 proofs are checked
+// together using a randomized linear combination and, if the batch check fails, or for other
+// proving schemes and curves, each proof is verified individually; the returned slice contains
+// the verification error of each proof, or nil if the proof was verified
+func (p *GnarkProverProvider) BatchVerify(proofs [][]byte, verifyingKey []byte, publicWitnesses [][]byte, srs []byte) ([]error, error) {
+	if len(proofs) != len(publicWitnesses) {
+		return nil, fmt.Errorf("failed to batch verify %d proof(s); %d public witness(es) provided", len(proofs), len(publicWitnesses))
+	}
+
+	results := make([]error, len(proofs))
+	if len(proofs) == 0 {
+		return results, nil
+	}
+
+	vk, err := p.cachedVerifyingKey(verifyingKey, srs)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.provingSchemeID == backend.GROTH16 && p.curveID == ecc.BN254 {
+		// the verifying key is not provided when it is resolved from the cache
+		if len(verifyingKey) == 0 {
+			buf := new(bytes.Buffer)
+			_, err = vk.(io.WriterTo).WriteTo(buf)
+			if err != nil {
+				return nil, err
+			}
+			verifyingKey = buf.Bytes()
+		}
+
+		ok, err := batchVerifyGroth16BN254(proofs, verifyingKey, publicWitnesses)
+		if err != nil {
+			common.Log.Debugf("groth16 batch check failed for %d proof(s); falling back to per-proof verification; %s", len(proofs), err.Error())
+		} else if ok {
+			return results, nil
+		}
+	}
+
+	for i := range proofs {
+		results[i] = p.verifyDecoded(proofs[i], vk, publicWitnesses[i])
+	}
+
+	return results, nil
+}
+
+// verifyDecoded verifies the given proof and binary-encoded public witness using the given decoded verifying key
+func (p *GnarkProverProvider) verifyDecoded(proof []byte, vk interface{}, publicWitness []byte) error {
+	prf, err := p.decodeProof(proof)
+	if err != nil {
+		return err
+	}
+
+	w, err := witness.New(p.curveID, nil)
+	if err != nil {
+		return err
+	}
+
+	err = w.UnmarshalBinary(publicWitness)
+	if err != nil {
+		return fmt.Errorf("failed to decode public witness; %s", err.Error())
+	}
+
+	switch p.provingSchemeID {
+	case backend.GROTH16:
+		return groth16.Verify(prf.(groth16.Proof), vk.(groth16.VerifyingKey), w)
+	case backend.PLONK:
+		return plonk.Verify(prf.(plonk.Proof), vk.(plonk.VerifyingKey), w)
+	}
+
+	return fmt.Errorf("invalid proving scheme for Verify")
+}
+
+// batchVerifyGroth16BN254 checks the given groth16 BN254 proofs together; for each proof i
+// with public input commitment L_i = K_0 + Σ x_ij.K_j, and random r_i, the batch is valid iff
+//
+//	Π e(r_i.A_i, B_i) . e(-Σ r_i.C_i, δ) . e(-Σ r_i.L_i, γ) . e(-(Σ r_i).α, β) = 1
+//
+// which holds with overwhelming probability only if each proof is valid
+func batchVerifyGroth16BN254(proofs [][]byte, verifyingKey []byte, publicWitnesses [][]byte) (bool, error) {
+	var alpha, betaG1, deltaG1 bn254.G1Affine
+	var beta, gamma, delta bn254.G2Affine
+	var k []bn254.G1Affine
+
+	dec := bn254.NewDecoder(bytes.NewReader(verifyingKey))
+	for _, v := range []interface{}{&alpha, &betaG1, &beta, &gamma, &deltaG1, &delta, &k} {
+		err := dec.Decode(v)
+		if err != nil {
+			return false, fmt.Errorf("failed to decode groth16 BN254 verifying key; %s", err.Error())
+		}
+	}
+
+	if len(k) == 0 {
+		return false, fmt.Errorf("failed to decode groth16 BN254 verifying key; no public input commitments")
+	}
+
+	modulus := fr.Modulus()
+	scalars := make([]*big.Int, len(k)) // Σ r_i.x_ij for each commitment K_j, where x_i0 = 1
+	for j := range scalars {
+		scalars[j] = new(big.Int)
+	}
+
+	g1 := make([]bn254.G1Affine, 0, len(proofs)+3)
+	g2 := make([]bn254.G2Affine, 0, len(proofs)+3)
+
+	var sumC bn254.G1Jac
+	for i := range proofs {
+		var ar, krs bn254.G1Affine
+		var bs bn254.G2Affine
+
+		dec := bn254.NewDecoder(bytes.NewReader(proofs[i]))
+		for _, v := range []interface{}{&ar, &bs, &krs} {
+			err := dec.Decode(v)
+			if err != nil {
+				return false, fmt.Errorf("failed to decode groth16 BN254 proof %d; %s", i, err.Error())
+			}
+		}
+
+		inputs, err := verifier.PublicInputs(publicWitnesses[i])
+		if err != nil {
+			return false, fmt.Errorf("failed to decode public witness %d; %s", i, err.Error())
+		}
+
+		if len(inputs) != len(k)-1 {
+			return false, fmt.Errorf("invalid public witness %d; %d input(s) provided; %d expected", i, len(inputs), len(k)-1)
+		}
+
+		var e fr.Element
+		_, err = e.SetRandom()
+		if err != nil {
+			return false, fmt.Errorf("failed to generate batch verification scalar; %s", err.Error())
+		}
+		r := e.ToBigIntRegular(new(big.Int))
+
+		var rAr bn254.G1Affine
+		rAr.ScalarMultiplication(&ar, r)
+		g1 = append(g1, rAr)
+		g2 = append(g2, bs)
+
+		var rKrs bn254.G1Jac
+		rKrs.FromAffine(&krs)
+		rKrs.ScalarMultiplication(&rKrs, r)
+		sumC.AddAssign(&rKrs)
+
+		scalars[0].Add(scalars[0], r)
+		for j, x := range inputs {
+			scalars[j+1].Add(scalars[j+1], new(big.Int).Mul(r, x))
+		}
+	}
+
+	var sumL bn254.G1Jac
+	for j := range k {
+		var kj bn254.G1Jac
+		kj.FromAffine(&k[j])
+		kj.ScalarMultiplication(&kj, scalars[j].Mod(scalars[j], modulus))
+		sumL.AddAssign(&kj)
+	}
+
+	var sumAlpha bn254.G1Affine
+	sumAlpha.ScalarMultiplication(&alpha, scalars[0])
+
+	var c, l bn254.G1Affine
+	c.FromJacobian(&sumC)
+	l.FromJacobian(&sumL)
+
+	c.Neg(&c)
+	l.Neg(&l)
+	sumAlpha.Neg(&sumAlpha)
+
+	g1 = append(g1, c, l, sumAlpha)
+	g2 = append(g2, delta, gamma, beta)
+
+	return bn254.PairingCheck(g1, g2)
+}
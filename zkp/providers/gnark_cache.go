@@ -0,0 +1,187 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/provideplatform/privacy/common"
+)
+
+const artifactCacheKindR1CS = "r1cs"
+const artifactCacheKindProvingKey = "pk"
+const artifactCacheKindVerifyingKey = "vk"
+
+// SetCacheScope scopes the decoded artifacts cached by the provider to a prover, i.e. to a prover id,
+// such that the cached artifacts can be invalidated using the scope as a prefix; artifacts are not
+// cached by a provider without a cache scope
+func (p *GnarkProverProvider) SetCacheScope(scope string) {
+	p.cacheScope = scope
+}
+
+// SetArtifactIDs identifies the proving key, verifying key and srs of the prover within the cache
+// scope by their vault secret ids, which are immutable; keys without an id are not cached
+func (p *GnarkProverProvider) SetArtifactIDs(provingKeyID, verifyingKeyID, srsID string) {
+	p.provingKeyID = provingKeyID
+	p.verifyingKeyID = verifyingKeyID
+	p.srsID = srsID
+}
+
+// SetArtifactSource sets the function used to resolve an encoded proving key, verifying key or srs
+// by its secret id when the encoded artifact is not provided, i.e. such that a prover resolves its
+// artifacts from vault only when the decoded artifacts are not cached
+func (p *GnarkProverProvider) SetArtifactSource(source func(id string) ([]byte, error)) {
+	p.artifactSource = source
+}
+
+// resolveArtifact returns the given encoded artifact, resolving it by the given id using the
+// artifact source if it was not provided
+func (p *GnarkProverProvider) resolveArtifact(encoded []byte, id string) ([]byte, error) {
+	if len(encoded) > 0 || p.artifactSource == nil || id == "" {
+		return encoded, nil
+	}
+
+	return p.artifactSource(id)
+}
+
+// artifactCacheKey returns the cache key for the given artifact kind, identified within the cache
+// scope by the given ids; returns false if the artifact is not identified. PLONK keys are
+// cached after KZG initialization, so the srs id is part of their cache key
+func (p *GnarkProverProvider) artifactCacheKey(kind string, ids ...string) (string, bool) {
+	if p.cacheScope == "" {
+		return "", false
+	}
+
+	if p.provingSchemeID == backend.PLONK && kind != artifactCacheKindR1CS {
+		ids = append(ids, p.srsID)
+	}
+
+	key := fmt.Sprintf("%s%s", p.cacheScope, kind)
+	for _, id := range ids {
+		if id == "" {
+			return "", false
+		}
+		key = fmt.Sprintf("%s/%s", key, id)
+	}
+
+	return key, true
+}
+
+// cachedR1CS returns the decoded constraint system for the given encoded R1CS, decoding and
+// caching it on a cache miss; the constraint system is identified within the cache scope by
+// the sha256 digest of the encoded R1CS, such that a recompiled prover is not served a stale
+// constraint system
+func (p *GnarkProverProvider) cachedR1CS(encodedR1CS []byte) (frontend.CompiledConstraintSystem, error) {
+	digest := sha256.Sum256(encodedR1CS)
+	key, cacheable := p.artifactCacheKey(artifactCacheKindR1CS, hex.EncodeToString(digest[:]))
+	if r1cs, ok := common.ArtifactCache.Get(key); cacheable && ok {
+		return r1cs.(frontend.CompiledConstraintSystem), nil
+	}
+
+	r1cs, err := p.decodeR1CS(encodedR1CS)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		common.ArtifactCache.Add(key, r1cs, common.SizeOf(r1cs))
+	}
+	return r1cs, nil
+}
+
+// cachedProvingKey returns the decoded proving key for the given encoded proving key, resolving,
+// decoding and caching it on a cache miss; PLONK proving keys are initialized using the given srs
+func (p *GnarkProverProvider) cachedProvingKey(provingKey, srs []byte) (interface{}, error) {
+	key, cacheable := p.artifactCacheKey(artifactCacheKindProvingKey, p.provingKeyID)
+	if pk, ok := common.ArtifactCache.Get(key); cacheable && ok {
+		return pk, nil
+	}
+
+	provingKey, err := p.resolveArtifact(provingKey, p.provingKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := p.decodeProvingKey(provingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.provingSchemeID == backend.PLONK {
+		srs, err := p.resolveArtifact(srs, p.srsID)
+		if err != nil {
+			return nil, err
+		}
+
+		kzgsrs := kzg.NewSRS(p.curveID)
+		kzgsrs.ReadFrom(bytes.NewReader(srs))
+		err = pk.(plonk.ProvingKey).InitKZG(kzgsrs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cacheable {
+		common.ArtifactCache.Add(key, pk, common.SizeOf(pk))
+	}
+	return pk, nil
+}
+
+// cachedVerifyingKey returns the decoded verifying key for the given encoded verifying key, resolving,
+// decoding and caching it on a cache miss; PLONK verifying keys are initialized using the given srs
+func (p *GnarkProverProvider) cachedVerifyingKey(verifyingKey, srs []byte) (interface{}, error) {
+	key, cacheable := p.artifactCacheKey(artifactCacheKindVerifyingKey, p.verifyingKeyID)
+	if vk, ok := common.ArtifactCache.Get(key); cacheable && ok {
+		return vk, nil
+	}
+
+	verifyingKey, err := p.resolveArtifact(verifyingKey, p.verifyingKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	vk, err := p.decodeVerifyingKey(verifyingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.provingSchemeID == backend.PLONK {
+		srs, err := p.resolveArtifact(srs, p.srsID)
+		if err != nil {
+			return nil, err
+		}
+
+		kzgsrs := kzg.NewSRS(p.curveID)
+		kzgsrs.ReadFrom(bytes.NewReader(srs))
+		err = vk.(plonk.VerifyingKey).InitKZG(kzgsrs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cacheable {
+		common.ArtifactCache.Add(key, vk, common.SizeOf(vk))
+	}
+	return vk, nil
+}
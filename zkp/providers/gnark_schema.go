@@ -0,0 +1,197 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/provideplatform/privacy/zkp/lib/circuits/gnark"
+)
+
+const witnessVisibilityPublic = "public"
+const witnessVisibilitySecret = "secret"
+
+var frontendVariableType = reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+
+// WitnessSchema describes the witness of the given library prover as a JSON Schema-like
+// object; slices which have not been allocated by the given compile-time variables are
+// listed with the _count variable required to allocate them
+func (p *GnarkProverProvider) WitnessSchema(identifier string, variables interface{}) (map[string]interface{}, error) {
+	w := p.ProverFactory(identifier)
+	if w == nil {
+		return nil, fmt.Errorf("failed to resolve witness schema; %s prover not resolved", identifier)
+	}
+
+	if vars, varsOk := variables.(map[string]interface{}); varsOk {
+		err := allocateVariablesForProver(w.(frontend.Circuit), vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve witness schema; %s", err.Error())
+		}
+	}
+
+	schema := &witnessSchema{
+		counts: make([]string, 0),
+		fields: make([]map[string]interface{}, 0),
+	}
+
+	var root map[string]interface{}
+	if named, namedOk := w.(namedWitnessCircuit); namedOk {
+		root = schema.describeNamed(named.WitnessFields())
+	} else {
+		root = schema.describe(reflect.Indirect(reflect.ValueOf(w)), "", witnessVisibilitySecret)
+	}
+
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = identifier
+	root["fields"] = schema.fields
+	root["counts"] = schema.counts
+
+	return root, nil
+}
+
+type witnessSchema struct {
+	counts []string
+	fields []map[string]interface{}
+}
+
+// describeNamed describes the named witness fields of a runtime circuit
+func (s *witnessSchema) describeNamed(fields []*gnark.NamedWitnessField) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := make([]string, 0)
+
+	for _, field := range fields {
+		visibility := witnessVisibilitySecret
+		if field.Public {
+			visibility = witnessVisibilityPublic
+		}
+
+		properties[field.Name] = s.describe(field.Value, field.Name, visibility)
+		required = append(required, field.Name)
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// describe recursively describes the given value at path, inheriting the given visibility
+func (s *witnessSchema) describe(val reflect.Value, path, visibility string) map[string]interface{} {
+	switch val.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := make([]string, 0)
+
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+
+			fieldVisibility, ignored := gnarkTagVisibility(field.Tag.Get("gnark"), visibility)
+			if ignored {
+				continue
+			}
+
+			properties[field.Name] = s.describe(val.Field(i), witnessSchemaPath(path, field.Name), fieldVisibility)
+			required = append(required, field.Name)
+		}
+
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+
+	case reflect.Array, reflect.Slice:
+		items := map[string]interface{}{}
+		description := map[string]interface{}{
+			"type": "array",
+		}
+
+		if val.Kind() == reflect.Slice && val.Len() == 0 {
+			count := fmt.Sprintf("%s_count", path)
+			s.counts = append(s.counts, count)
+			description["count_variable"] = count
+			items = s.describe(reflect.New(val.Type().Elem()).Elem(), fmt.Sprintf("%s[]", path), visibility)
+		} else {
+			for i := 0; i < val.Len(); i++ {
+				item := s.describe(val.Index(i), fmt.Sprintf("%s[%d]", path, i), visibility)
+				if i == 0 {
+					items = item
+				}
+			}
+			description["minItems"] = val.Len()
+			description["maxItems"] = val.Len()
+		}
+
+		description["items"] = items
+		return description
+	}
+
+	if val.Type() == frontendVariableType || val.Kind() == reflect.Interface {
+		if !strings.Contains(path, "[]") {
+			s.fields = append(s.fields, map[string]interface{}{
+				"path":       path,
+				"visibility": visibility,
+			})
+		}
+
+		return map[string]interface{}{
+			"oneOf": []map[string]interface{}{
+				{"type": "string", "pattern": "^(0x[0-9a-fA-F]+|[0-9]+)$"},
+				{"type": "integer"},
+			},
+			"visibility": visibility,
+		}
+	}
+
+	return map[string]interface{}{
+		"description": fmt.Sprintf("unsupported witness type %s", val.Type().String()),
+	}
+}
+
+// gnarkTagVisibility parses the visibility from the given gnark struct tag, i.e., `gnark:",public"`;
+// fields without an explicit visibility inherit the visibility of the parent
+func gnarkTagVisibility(tag, parent string) (string, bool) {
+	if tag == "-" {
+		return "", true
+	}
+
+	opts := strings.Split(tag, ",")
+	for _, opt := range opts[1:] {
+		switch strings.TrimSpace(opt) {
+		case witnessVisibilityPublic:
+			return witnessVisibilityPublic, false
+		case witnessVisibilitySecret:
+			return witnessVisibilitySecret, false
+		}
+	}
+
+	return parent, false
+}
+
+func witnessSchemaPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", parent, name)
+}
@@ -0,0 +1,292 @@
+/*
+ * Copyright 2017-2022 Provide Technologies Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verifier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/provideplatform/privacy/common"
+)
+
+// VerifierContractName is the name of the contract emitted by the gnark solidity export
+const VerifierContractName = "Verifier"
+
+const verifierMethodVerifyProof = "verifyProof"
+
+const simulatedChainID = 1337
+const simulatedGasLimit = uint64(30000000)
+
+var simulatedBalance = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+
+// solcPath is the path to the solc binary used to compile exported verifier contracts
+var solcPath string
+
+func init() {
+	solcPath = os.Getenv("SOLC_PATH")
+	if solcPath == "" {
+		solcPath = "solc"
+	}
+}
+
+// CompiledContract is the ABI and creation bytecode for a compiled contract
+type CompiledContract struct {
+	Name     string
+	ABI      []byte
+	Bytecode []byte
+}
+
+// EVMVerifier is a verifier contract deployed to an in-process simulated chain
+type EVMVerifier struct {
+	Address         ethcommon.Address
+	TransactionHash ethcommon.Hash
+
+	abi      abi.ABI
+	backend  *backends.SimulatedBackend
+	contract *bind.BoundContract
+}
+
+// SolcAvailable returns true if the configured solc binary can be resolved
+func SolcAvailable() bool {
+	_, err := exec.LookPath(solcPath)
+	return err == nil
+}
+
+// CompileVerifier compiles the given solidity verifier source using solc
+func CompileVerifier(source []byte) (*CompiledContract, error) {
+	return CompileContract(source, VerifierContractName)
+}
+
+// CompileContract compiles the given solidity source using solc and returns the named contract
+func CompileContract(source []byte, name string) (*CompiledContract, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(solcPath, "--combined-json", "abi,bin", "-")
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile %s contract using %s; %s; %s", name, solcPath, err.Error(), stderr.String())
+	}
+
+	contracts, err := compiler.ParseCombinedJSON(stdout.Bytes(), string(source), "", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compiled %s contract; %s", name, err.Error())
+	}
+
+	for contractName, contract := range contracts {
+		if contractName != name && !strings.HasSuffix(contractName, fmt.Sprintf(":%s", name)) {
+			continue
+		}
+
+		abiJSON, err := json.Marshal(contract.Info.AbiDefinition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s contract abi; %s", name, err.Error())
+		}
+
+		bytecode, err := hexutil.Decode(contract.Code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s contract bytecode; %s", name, err.Error())
+		}
+
+		return &CompiledContract{
+			Name:     name,
+			ABI:      abiJSON,
+			Bytecode: bytecode,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("failed to resolve %s contract in compiled source", name)
+}
+
+// NewSimulatedBackend initializes an in-process simulated chain and a funded transactor
+func NewSimulatedBackend() (*backends.SimulatedBackend, *bind.TransactOpts, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate simulated chain deployer key; %s", err.Error())
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(simulatedChainID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize simulated chain transactor; %s", err.Error())
+	}
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: simulatedBalance},
+	}, simulatedGasLimit)
+
+	return backend, auth, nil
+}
+
+// NewSimulatedEVMVerifier deploys the compiled verifier to a new in-process simulated chain
+func NewSimulatedEVMVerifier(contract *CompiledContract) (*EVMVerifier, error) {
+	backend, auth, err := NewSimulatedBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := DeployEVMVerifier(backend, auth, contract)
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+
+	return verifier, nil
+}
+
+// DeployEVMVerifier deploys the compiled verifier to the given simulated chain
+func DeployEVMVerifier(backend *backends.SimulatedBackend, auth *bind.TransactOpts, contract *CompiledContract) (*EVMVerifier, error) {
+	parsedABI, err := abi.JSON(bytes.NewReader(contract.ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse verifier contract abi; %s", err.Error())
+	}
+
+	address, tx, bound, err := bind.DeployContract(auth, parsedABI, contract.Bytecode, backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy verifier contract to simulated chain; %s", err.Error())
+	}
+	backend.Commit()
+
+	common.Log.Debugf("deployed verifier contract to simulated chain at address %s; tx hash: %s", address.Hex(), tx.Hash().Hex())
+
+	return &EVMVerifier{
+		Address:         address,
+		TransactionHash: tx.Hash(),
+		abi:             parsedABI,
+		backend:         backend,
+		contract:        bound,
+	}, nil
+}
+
+// Close releases the underlying simulated chain
+func (v *EVMVerifier) Close() error {
+	return v.backend.Close()
+}
+
+// Verify calls verifyProof on the deployed verifier contract using the given
+// binary-encoded groth16 BN254 proof and public inputs
+func (v *EVMVerifier) Verify(proof []byte, publicInputs []*big.Int) (bool, error) {
+	a, b, c, err := Groth16BN254ProofCalldata(proof)
+	if err != nil {
+		return false, err
+	}
+
+	method, methodOk := v.abi.Methods[verifierMethodVerifyProof]
+	if !methodOk || len(method.Inputs) != 4 {
+		return false, fmt.Errorf("failed to resolve %s method on verifier contract", verifierMethodVerifyProof)
+	}
+
+	inputType := method.Inputs[3].Type
+	if inputType.Size != len(publicInputs) {
+		return false, fmt.Errorf("verifier contract expects %d public inputs; %d provided", inputType.Size, len(publicInputs))
+	}
+
+	input := reflect.New(inputType.GetType()).Elem()
+	for i, val := range publicInputs {
+		input.Index(i).Set(reflect.ValueOf(val))
+	}
+
+	var out []interface{}
+	err = v.contract.Call(&bind.CallOpts{}, &out, verifierMethodVerifyProof, a, b, c, input.Interface())
+	if err != nil {
+		return false, fmt.Errorf("failed to call %s on verifier contract %s; %s", verifierMethodVerifyProof, v.Address.Hex(), err.Error())
+	}
+
+	if len(out) != 1 {
+		return false, fmt.Errorf("unexpected result from %s on verifier contract %s", verifierMethodVerifyProof, v.Address.Hex())
+	}
+
+	result, resultOk := out[0].(bool)
+	if !resultOk {
+		return false, fmt.Errorf("unexpected result type %T from %s on verifier contract %s", out[0], verifierMethodVerifyProof, v.Address.Hex())
+	}
+
+	return result, nil
+}
+
+// Groth16BN254ProofCalldata decodes the given binary-encoded (compressed or raw) groth16
+// BN254 proof into the a, b and c arguments expected by the exported verifier contract;
+// G2 coordinates are ordered as (A1, A0) per the EIP-197 precompile
+func Groth16BN254ProofCalldata(proof []byte) (a [2]*big.Int, b [2][2]*big.Int, c [2]*big.Int, err error) {
+	var ar, krs bn254.G1Affine
+	var bs bn254.G2Affine
+
+	dec := bn254.NewDecoder(bytes.NewReader(proof))
+	for _, p := range []interface{}{&ar, &bs, &krs} {
+		if err = dec.Decode(p); err != nil {
+			err = fmt.Errorf("failed to decode groth16 BN254 proof; %s", err.Error())
+			return
+		}
+	}
+
+	a[0] = ar.X.ToBigIntRegular(new(big.Int))
+	a[1] = ar.Y.ToBigIntRegular(new(big.Int))
+
+	b[0][0] = bs.X.A1.ToBigIntRegular(new(big.Int))
+	b[0][1] = bs.X.A0.ToBigIntRegular(new(big.Int))
+	b[1][0] = bs.Y.A1.ToBigIntRegular(new(big.Int))
+	b[1][1] = bs.Y.A0.ToBigIntRegular(new(big.Int))
+
+	c[0] = krs.X.ToBigIntRegular(new(big.Int))
+	c[1] = krs.Y.ToBigIntRegular(new(big.Int))
+
+	return a, b, c, nil
+}
+
+// PublicInputs decodes the given binary-encoded public witness vector, as written
+// by gnark, into field elements suitable for use as verifier contract inputs
+func PublicInputs(publicWitness []byte) ([]*big.Int, error) {
+	if len(publicWitness) < 4 {
+		return nil, fmt.Errorf("failed to decode public witness; invalid length %d", len(publicWitness))
+	}
+
+	n := int(binary.BigEndian.Uint32(publicWitness[:4]))
+	if n == 0 {
+		return []*big.Int{}, nil
+	}
+
+	buf := publicWitness[4:]
+	if len(buf)%n != 0 {
+		return nil, fmt.Errorf("failed to decode public witness; %d bytes not divisible into %d elements", len(buf), n)
+	}
+
+	size := len(buf) / n
+	inputs := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		inputs[i] = new(big.Int).SetBytes(buf[i*size : (i+1)*size])
+	}
+
+	return inputs, nil
+}